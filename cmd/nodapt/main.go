@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/axetroy/nodapt/internal/command"
 	"github.com/axetroy/nodapt/internal/util"
@@ -41,32 +45,135 @@ USAGE:
   nodapt [OPTIONS] use <CONSTRAINT> [ARGS...>
   nodapt [OPTIONS] rm <CONSTRAINT>
   nodapt [OPTIONS] clean
-  nodapt [OPTIONS] ls
-  nodapt [OPTIONS] ls-remote
+  nodapt [OPTIONS] prune [PRUNE OPTIONS]
+  nodapt [OPTIONS] ls [LS OPTIONS]
+  nodapt [OPTIONS] ls-remote [LS-REMOTE OPTIONS]
+  nodapt [OPTIONS] current [--install]
+  nodapt [OPTIONS] which [--install] <BIN>
+  nodapt [OPTIONS] doctor [--json]
+  nodapt [OPTIONS] default [CONSTRAINT]
+  nodapt [OPTIONS] alias [ls | <NAME> <CONSTRAINT>]
+  nodapt [OPTIONS] unalias <NAME>
+  nodapt [OPTIONS] pin [--nvmrc | --node-version] [CONSTRAINT]
+  nodapt [OPTIONS] lock
+  nodapt [OPTIONS] upgrade [UPGRADE OPTIONS] [CONSTRAINT]
+  nodapt [OPTIONS] audit [AUDIT OPTIONS]
+  nodapt [OPTIONS] globals ls [--json] <CONSTRAINT>
+  nodapt [OPTIONS] globals migrate --from <CONSTRAINT> --to <CONSTRAINT>
+  nodapt [OPTIONS] each [EACH OPTIONS] -- <ARGS...>
+  nodapt [OPTIONS] matrix [MATRIX OPTIONS] [CONSTRAINT...] -- <ARGS...>
 
 COMMANDS:
   <ARGS...>                   Alias for 'run <ARGS...>' but shorter
   run <ARGS...>               Automatically select node version to run commands
+                              The packageManager of package.json, e.g. pnpm@9.1.0, is installed and comes first in PATH
+                              Versions bundling an npm that satisfies engines.npm are preferred
+                              The constraint comes from devEngines.runtime, volta.node or engines.node of package.json
   use <CONSTRAINT> <ARGS...>  Use the specified version of node to run the command
   rm|remove <CONSTRAINT>      Remove the specified version of node that installed by nodapt
   clean                       Remove all the node version that installed by nodapt
-  ls|list                     List all the installed node version
-  ls-remote|list-remote       List all the available node version
+  prune [PRUNE OPTIONS]       Remove the least recently used node versions that installed by nodapt
+  ls|list [LS OPTIONS]        List all the installed node version with size, platform and usage
+  ls-remote|list-remote [LS-REMOTE OPTIONS]
+                              List all the available node version with release date, LTS and bundled versions
+  current [--install]         Print the node version that run uses in the current directory and where the constraint comes from
+  which [--install] <BIN>     Print the absolute path of node, npm or a global package binary of that version
+                              Nothing is installed unless --install is passed
+  doctor [--json]             Diagnose the mirror, the installed versions, PATH and shell detection
+  default [CONSTRAINT]        Set the constraint used when the project doesn't specify one, or print it
+  alias <NAME> <CONSTRAINT>   Name a constraint, the name can be used wherever a constraint is expected
+  alias [ls]                  List all the aliases
+  unalias <NAME>              Remove the alias
+  pin [CONSTRAINT]            Write the constraint into engines.node of the nearest package.json
+                              Defaults to a caret range of the active node, or of the latest LTS without one
+  lock                        Record the newest version matching engines.node and its SHA-256 in nodapt.lock
+                              When nodapt.lock exists, run uses exactly that version and verifies the download
+  upgrade [CONSTRAINT]        Install the newest release of every installed major, or of the majors matching the constraint
+  audit [AUDIT OPTIONS]       Check the installed versions and the project constraint for end-of-life and security releases
+  globals ls <CONSTRAINT>     List the global npm packages of the installed version
+  globals migrate --from <CONSTRAINT> --to <CONSTRAINT>
+                              Reinstall the global npm packages of a version into another one with its npm
+  each -- <ARGS...>           Run the command in every package of the workspace with the node of the package
+                              Fails if the command fails in any package
+  matrix [CONSTRAINT...] -- <ARGS...>
+                              Run the command with the newest version matching every constraint and print the results
+                              Fails if the command fails with any version
 
 GLOBAL OPTIONS:
   --help|-h                   Print help information
   --version|-v                Print version information
+  --frozen-lockfile           Fail if nodapt.lock is missing or no longer satisfies engines.node
+  --with-headers              Download the headers of the node version from the mirror for node-gyp
+                              Once downloaded, npm_config_nodedir points to them so native addons build offline
+  --intersect-workspace       Select a version satisfying both the package and the workspace root
+                              Without it, a workspace package only inherits the constraint of the root when it has none
+
+LS OPTIONS:
+  --json                      Print the result as JSON
+  --constraint <CONSTRAINT>   Only list the versions which satisfy the constraint
+
+LS-REMOTE OPTIONS:
+  --lts                       Only list LTS releases
+  --constraint <CONSTRAINT>   Only list the releases which satisfy the constraint
+  --major-latest              Only list the newest release of every major
+  --since <DATE>              Only list the releases published on or after the date, e.g. 2024-01-01
+  --limit <N>                 List at most N releases
+  --json                      Print the result as JSON
+
+PRUNE OPTIONS:
+  --keep-latest-per-major     Keep the newest installed version of every major
+  --unused-for <DURATION>     Only remove the versions not used for the duration, e.g. 30d
+  --max-size <SIZE>           Remove the least recently used versions until the total size fits, e.g. 5GB
+  --dry-run                   Print what would be removed without removing anything
+                              The version required by the current project is never removed
+
+PIN OPTIONS:
+  --nvmrc                     Write the .nvmrc file next to package.json instead
+  --node-version              Write the .node-version file next to package.json instead
+                              The version files get the exact version when no constraint is given
+
+UPGRADE OPTIONS:
+  --prune                     Remove the versions superseded by the upgrade
+  --migrate-globals           Reinstall the global npm packages of the superseded version into the new one
+
+AUDIT OPTIONS:
+  --fail-on <eol|security>    Exit with an error when a version is past its end-of-life or has a newer security release
+                              Separate several values with a comma, e.g. --fail-on=eol,security
+  --update-schedule           Download the latest release schedule of Node.js before the audit
+  --json                      Print the result as JSON
+
+EACH OPTIONS:
+  --filter <GLOB>             Only run in the packages whose name or path matches the glob, e.g. @scope/* or apps/**
+  --parallel <N>              Run in N packages at the same time, defaults to 1
+
+MATRIX OPTIONS:
+  --from-engines              Also run with the newest release of every major admitted by engines.node
+  --parallel <N>              Run with N versions at the same time, the output of each is printed when it exits
+  --junit <FILE>              Write the results into the file as JUnit XML
 
 GLOBAL ENVIRONMENT VARIABLES:
   NODE_MIRROR                 The mirror of the nodejs download, defaults to: https://nodejs.org/dist/
                               Chinese users defaults to: https://registry.npmmirror.com/-/binary/node/
   NODE_ENV_DIR                The directory where the nodejs is stored, defaults to: $HOME/.nodapt
+  NODAPT_NPM_REGISTRY         The registry of the packageManager of package.json, defaults to NPM_CONFIG_REGISTRY or https://registry.npmjs.org/
+  NODE_SCHEDULE_URL           The release schedule of Node.js, defaults to: https://raw.githubusercontent.com/nodejs/Release/main/schedule.json
+  NODAPT_GLOBALS              Where npm installs global packages: version (default), major or shared
+                              With major or shared, the global packages are kept in $NODE_ENV_DIR/globals across versions
+  NODAPT_WITH_HEADERS         Same as --with-headers when set NODAPT_WITH_HEADERS=1
+  NODAPT_WORKSPACE_INTERSECT  Same as --intersect-workspace when set NODAPT_WORKSPACE_INTERSECT=1
+  NODAPT_NO_AUDIT             Hide the end-of-life and security release warnings of run and use when set NODAPT_NO_AUDIT=1
+  NODAPT_EXEC                 Replace nodapt with the command instead of running it as a child when set NODAPT_EXEC=1 (Unix only)
   DEBUG                       Print debug information when set DEBUG=1
 
 EXAMPLES:
   nodapt node -v
   nodapt run node -v
   nodapt use v14.17.0 node -v
+  nodapt default 20
+  nodapt alias work-lts ^18 && nodapt use work-lts
+  nodapt prune --keep-latest-per-major --unused-for 30d
+  nodapt each --filter "@scope/*" --parallel 4 -- npm test
+  nodapt matrix --junit junit.xml 18 20 22 -- npm test
 
 SOURCE CODE:
   https://github.com/axetroy/nodapt`)
@@ -78,12 +185,19 @@ func main() {
 	helpShortFlag := flag.Bool("h", false, "Print help information")
 	versionLongFlag := flag.Bool("version", false, "Print version information")
 	versionShortFlag := flag.Bool("v", false, "Print version information")
+	frozenLockfileFlag := flag.Bool("frozen-lockfile", false, "Fail if the lockfile is missing or outdated")
+	withHeadersFlag := flag.Bool("with-headers", false, "Download the headers of the node version for node-gyp")
+	intersectWorkspaceFlag := flag.Bool("intersect-workspace", false, "Intersect the node constraint of a workspace package with the root")
 
 	flag.Parse()
 
 	showHelp := *helpLongFlag || *helpShortFlag
 	showVersion := *versionLongFlag || *versionShortFlag
 
+	command.SetFrozenLockfile(*frozenLockfileFlag)
+	command.SetWithHeaders(*withHeadersFlag)
+	command.SetWorkspaceIntersect(*intersectWorkspaceFlag)
+
 	util.Debug("args %v\n", os.Args)
 
 	args := flag.Args()
@@ -103,7 +217,7 @@ func main() {
 		constraint := args[1]
 		commands := args[2:]
 		if len(commands) == 0 {
-			if err := command.Use(&constraint); err != nil {
+			if err := command.Use(constraint); err != nil {
 				handleError(err)
 			}
 		} else {
@@ -125,12 +239,137 @@ func main() {
 		if err := command.Clean(); err != nil {
 			handleError(err)
 		}
+	case "prune":
+		options, err := parsePruneOptions(args[1:])
+		if err != nil {
+			handleError(err)
+		}
+		if err := command.Prune(options); err != nil {
+			handleError(err)
+		}
 	case "list", "ls":
-		if err := command.List(); err != nil {
+		if err := command.List(parseListOptions(args[1:])); err != nil {
 			handleError(err)
 		}
 	case "list-remote", "ls-remote":
-		if err := command.ListRemote(); err != nil {
+		options, err := parseListRemoteOptions(args[1:])
+		if err != nil {
+			handleError(err)
+		}
+		if err := command.ListRemote(options); err != nil {
+			handleError(err)
+		}
+	case "current":
+		if err := command.Current(parseCurrentOptions(args[1:])); err != nil {
+			handleError(err)
+		}
+	case "which":
+		bin, options := parseWhichOptions(args[1:])
+		if bin == "" {
+			fmt.Println("Error: 'which' command requires a binary name.")
+			return
+		}
+		if err := command.Which(bin, options); err != nil {
+			handleError(err)
+		}
+	case "doctor":
+		if err := command.Doctor(parseDoctorOptions(args[1:])); err != nil {
+			handleError(err)
+		}
+	case "default":
+		var constraint *string
+		if len(args) > 1 {
+			constraint = &args[1]
+		}
+		if err := command.Default(constraint); err != nil {
+			handleError(err)
+		}
+	case "alias":
+		if len(args) == 1 || (len(args) == 2 && args[1] == "ls") {
+			if err := command.ListAliases(); err != nil {
+				handleError(err)
+			}
+			return
+		}
+		if len(args) < 3 {
+			fmt.Println("Error: 'alias' command requires a name and a version constraint.")
+			return
+		}
+		if err := command.SetAlias(args[1], args[2]); err != nil {
+			handleError(err)
+		}
+	case "unalias":
+		if len(args) < 2 {
+			fmt.Println("Error: 'unalias' command requires an alias name.")
+			return
+		}
+		if err := command.RemoveAlias(args[1]); err != nil {
+			handleError(err)
+		}
+	case "pin":
+		options, err := parsePinOptions(args[1:])
+		if err != nil {
+			fmt.Printf("Error: %s.\n", err)
+			return
+		}
+		if err := command.Pin(options); err != nil {
+			handleError(err)
+		}
+	case "upgrade":
+		if err := command.Upgrade(parseUpgradeOptions(args[1:])); err != nil {
+			handleError(err)
+		}
+	case "audit":
+		if err := command.Audit(parseAuditOptions(args[1:])); err != nil {
+			handleError(err)
+		}
+	case "globals":
+		if len(args) < 2 {
+			fmt.Println("Error: 'globals' command requires a subcommand, 'ls' or 'migrate'.")
+			return
+		}
+		switch args[1] {
+		case "ls", "list":
+			constraint, options := parseGlobalsListOptions(args[2:])
+			if constraint == "" {
+				fmt.Println("Error: 'globals ls' command requires a version constraint.")
+				return
+			}
+			if err := command.ListGlobals(constraint, options); err != nil {
+				handleError(err)
+			}
+		case "migrate":
+			options := parseGlobalsMigrateOptions(args[2:])
+			if options.From == "" || options.To == "" {
+				fmt.Println("Error: 'globals migrate' command requires --from and --to.")
+				return
+			}
+			if err := command.MigrateGlobals(options); err != nil {
+				handleError(err)
+			}
+		default:
+			fmt.Printf("Unknown globals command: %s\n", args[1])
+		}
+	case "lock":
+		if err := command.Lock(); err != nil {
+			handleError(err)
+		}
+	case "matrix":
+		separator := slices.Index(args, "--")
+		if separator < 0 || separator == len(args)-1 {
+			fmt.Println("Error: 'matrix' command requires a command to run after --.")
+			return
+		}
+		if err := command.Matrix(parseMatrixOptions(args[1:separator], args[separator+1:])); err != nil {
+			handleError(err)
+		}
+	case "each":
+		options := parseEachOptions(args[1:])
+		if len(options.Cmd) == 0 {
+			fmt.Println("Error: 'each' command requires a command to run.")
+			return
+		}
+		if err := command.Each(options); err != nil {
 			handleError(err)
 		}
 	case "run":
@@ -144,7 +383,269 @@ func main() {
 	}
 }
 
+func parseListOptions(args []string) *command.ListOptions {
+	flags := flag.NewFlagSet("ls", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "Print the result as JSON")
+	constraint := flags.String("constraint", "", "Only list the versions which satisfy the constraint")
+
+	flags.Parse(args)
+
+	options := &command.ListOptions{
+		JSON: *jsonOutput,
+	}
+
+	if *constraint != "" {
+		options.Constraint = constraint
+	}
+
+	return options
+}
+
+func parseListRemoteOptions(args []string) (*command.ListRemoteOptions, error) {
+	flags := flag.NewFlagSet("ls-remote", flag.ExitOnError)
+	lts := flags.Bool("lts", false, "Only list LTS releases")
+	constraint := flags.String("constraint", "", "Only list the releases which satisfy the constraint")
+	majorLatest := flags.Bool("major-latest", false, "Only list the newest release of every major")
+	since := flags.String("since", "", "Only list the releases published on or after the date")
+	limit := flags.Int("limit", 0, "List at most this many releases")
+	jsonOutput := flags.Bool("json", false, "Print the result as JSON")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	options := &command.ListRemoteOptions{
+		LTS:         *lts,
+		MajorLatest: *majorLatest,
+		Limit:       *limit,
+		JSON:        *jsonOutput,
+	}
+
+	if *constraint != "" {
+		options.Constraint = constraint
+	}
+
+	if *since != "" {
+		date, err := time.Parse(time.DateOnly, *since)
+		if err != nil {
+			return nil, errors.New("invalid date for --since, expected YYYY-MM-DD")
+		}
+		options.Since = &date
+	}
+
+	return options, nil
+}
+
+func parsePruneOptions(args []string) (*command.PruneOptions, error) {
+	flags := flag.NewFlagSet("prune", flag.ExitOnError)
+	keepLatestPerMajor := flags.Bool("keep-latest-per-major", false, "Keep the newest installed version of every major")
+	unusedFor := flags.String("unused-for", "", "Only remove the versions not used for the duration")
+	maxSize := flags.String("max-size", "", "Remove the least recently used versions until the total size fits")
+	dryRun := flags.Bool("dry-run", false, "Print what would be removed without removing anything")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	options := &command.PruneOptions{
+		KeepLatestPerMajor: *keepLatestPerMajor,
+		DryRun:             *dryRun,
+	}
+
+	if *unusedFor != "" {
+		d, err := util.ParseDuration(*unusedFor)
+		if err != nil {
+			return nil, err
+		}
+		options.UnusedFor = &d
+	}
+
+	if *maxSize != "" {
+		size, err := util.ParseSize(*maxSize)
+		if err != nil {
+			return nil, err
+		}
+		options.MaxSize = &size
+	}
+
+	return options, nil
+}
+
+func parseCurrentOptions(args []string) *command.CurrentOptions {
+	flags := flag.NewFlagSet("current", flag.ExitOnError)
+	install := flags.Bool("install", false, "Install the version if it is not installed yet")
+
+	flags.Parse(args)
+
+	return &command.CurrentOptions{Install: *install}
+}
+
+// parseWhichOptions returns the name of the binary, which is empty if it is missing, and the options of which.
+func parseWhichOptions(args []string) (string, *command.WhichOptions) {
+	flags := flag.NewFlagSet("which", flag.ExitOnError)
+	install := flags.Bool("install", false, "Install the version if it is not installed yet")
+
+	flags.Parse(args)
+
+	return flags.Arg(0), &command.WhichOptions{Install: *install}
+}
+
+func parseDoctorOptions(args []string) *command.DoctorOptions {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "Print the result as JSON")
+
+	flags.Parse(args)
+
+	return &command.DoctorOptions{JSON: *jsonOutput}
+}
+
+func parsePinOptions(args []string) (*command.PinOptions, error) {
+	flags := flag.NewFlagSet("pin", flag.ExitOnError)
+	nvmrc := flags.Bool("nvmrc", false, "Write the .nvmrc file instead")
+	nodeVersion := flags.Bool("node-version", false, "Write the .node-version file instead")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	options := &command.PinOptions{File: command.PinPackageJSON}
+
+	switch {
+	case *nvmrc && *nodeVersion:
+		return nil, errors.New("'--nvmrc' and '--node-version' cannot be used together")
+	case *nvmrc:
+		options.File = command.PinNvmrc
+	case *nodeVersion:
+		options.File = command.PinNodeVersion
+	}
+
+	if flags.NArg() > 0 {
+		constraint := flags.Arg(0)
+		options.Constraint = &constraint
+	}
+
+	return options, nil
+}
+
+func parseUpgradeOptions(args []string) *command.UpgradeOptions {
+	flags := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	prune := flags.Bool("prune", false, "Remove the versions superseded by the upgrade")
+	migrateGlobals := flags.Bool("migrate-globals", false, "Reinstall the global npm packages into the new version")
+
+	flags.Parse(args)
+
+	options := &command.UpgradeOptions{
+		Prune:          *prune,
+		MigrateGlobals: *migrateGlobals,
+	}
+
+	if flags.NArg() > 0 {
+		constraint := flags.Arg(0)
+		options.Constraint = &constraint
+	}
+
+	return options
+}
+
+func parseAuditOptions(args []string) *command.AuditOptions {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	failOn := flags.String("fail-on", "", "Exit with an error on eol or security problems")
+	updateSchedule := flags.Bool("update-schedule", false, "Download the latest release schedule")
+	jsonOutput := flags.Bool("json", false, "Print the result as JSON")
+
+	flags.Parse(args)
+
+	options := &command.AuditOptions{
+		JSON:           *jsonOutput,
+		UpdateSchedule: *updateSchedule,
+	}
+
+	if *failOn != "" {
+		options.FailOn = strings.Split(*failOn, ",")
+	}
+
+	return options
+}
+
+// parseGlobalsListOptions returns the version constraint, which is empty if it is missing, and the options of globals ls.
+func parseGlobalsListOptions(args []string) (string, *command.GlobalsListOptions) {
+	flags := flag.NewFlagSet("globals ls", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "Print the result as JSON")
+
+	flags.Parse(args)
+
+	return flags.Arg(0), &command.GlobalsListOptions{JSON: *jsonOutput}
+}
+
+func parseGlobalsMigrateOptions(args []string) *command.GlobalsMigrateOptions {
+	flags := flag.NewFlagSet("globals migrate", flag.ExitOnError)
+	from := flags.String("from", "", "The installed version to migrate from")
+	to := flags.String("to", "", "The version to migrate to")
+
+	flags.Parse(args)
+
+	return &command.GlobalsMigrateOptions{From: *from, To: *to}
+}
+
+func parseEachOptions(args []string) *command.EachOptions {
+	flags := flag.NewFlagSet("each", flag.ExitOnError)
+	filter := flags.String("filter", "", "Only run in the packages whose name or path matches the glob")
+	parallel := flags.Int("parallel", 1, "Run in N packages at the same time")
+
+	// The command starts at the first argument which is not a flag, or after --
+	flags.Parse(args)
+
+	return &command.EachOptions{
+		Filter:   *filter,
+		Parallel: *parallel,
+		Cmd:      flags.Args(),
+	}
+}
+
+func parseMatrixOptions(args []string, cmd []string) *command.MatrixOptions {
+	flags := flag.NewFlagSet("matrix", flag.ExitOnError)
+	fromEngines := flags.Bool("from-engines", false, "Also run with the newest release of every major admitted by engines.node")
+	parallel := flags.Int("parallel", 1, "Run with N versions at the same time")
+	junit := flags.String("junit", "", "Write the results into the file as JUnit XML")
+
+	constraints := make([]string, 0)
+
+	// Parse stops at the first constraint, so the flags after it are parsed from the rest again
+	for {
+		flags.Parse(args)
+
+		if flags.NArg() == 0 {
+			break
+		}
+
+		constraints = append(constraints, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	return &command.MatrixOptions{
+		Constraints: constraints,
+		FromEngines: *fromEngines,
+		Parallel:    *parallel,
+		JUnit:       *junit,
+		Cmd:         cmd,
+	}
+}
+
 func handleError(err error) {
+	var exitErr *exec.ExitError
+
+	// The child process has already reported its failure, just pass its exit code through
+	if errors.As(err, &exitErr) {
+		util.Debug("%+v\n", err)
+
+		// Follow the shell convention of 128+N for a child terminated by signal N
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			os.Exit(128 + int(status.Signal()))
+		}
+
+		os.Exit(exitErr.ExitCode())
+	}
+
 	if os.Getenv("DEBUG") == "1" {
 		fmt.Fprintf(os.Stderr, "%+v\n", err)
 	} else {
@@ -152,13 +653,5 @@ func handleError(err error) {
 		fmt.Fprintln(os.Stderr, "Print debug information when set DEBUG=1")
 	}
 
-	if exitErr, ok := err.(*exec.ExitError); ok {
-		os.Exit(exitErr.ExitCode())
-	} else if unwrappedErr := errors.Unwrap(err); unwrappedErr != nil {
-		if exitErr, ok := unwrappedErr.(*exec.ExitError); ok {
-			os.Exit(exitErr.ExitCode())
-		}
-	}
-
 	os.Exit(1)
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/axetroy/nodapt/internal/command"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMatrixOptions(t *testing.T) {
+	cmd := []string{"npm", "test"}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected *command.MatrixOptions
+	}{
+		{
+			name: "flags before constraints",
+			args: []string{"--parallel", "2", "18", "20"},
+			expected: &command.MatrixOptions{
+				Constraints: []string{"18", "20"},
+				Parallel:    2,
+				Cmd:         cmd,
+			},
+		},
+		{
+			name: "flags between and after constraints",
+			args: []string{"18", "--parallel", "2", "20", "--junit", "junit.xml"},
+			expected: &command.MatrixOptions{
+				Constraints: []string{"18", "20"},
+				Parallel:    2,
+				JUnit:       "junit.xml",
+				Cmd:         cmd,
+			},
+		},
+		{
+			name: "only from engines",
+			args: []string{"--from-engines"},
+			expected: &command.MatrixOptions{
+				Constraints: []string{},
+				FromEngines: true,
+				Parallel:    1,
+				Cmd:         cmd,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseMatrixOptions(tt.args, cmd))
+		})
+	}
+}
+
+func TestParseEachOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected *command.EachOptions
+	}{
+		{
+			name: "command after flags",
+			args: []string{"--filter", "@scope/*", "npm", "test", "--coverage"},
+			expected: &command.EachOptions{
+				Filter:   "@scope/*",
+				Parallel: 1,
+				Cmd:      []string{"npm", "test", "--coverage"},
+			},
+		},
+		{
+			name: "command after separator",
+			args: []string{"--parallel", "4", "--", "npm", "test"},
+			expected: &command.EachOptions{
+				Parallel: 4,
+				Cmd:      []string{"npm", "test"},
+			},
+		},
+		{
+			name: "without command",
+			args: []string{"--parallel", "4"},
+			expected: &command.EachOptions{
+				Parallel: 4,
+				Cmd:      []string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseEachOptions(tt.args))
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// DefaultAlias is the alias used as the constraint outside of any project.
+const DefaultAlias = "default"
+
+var aliasNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+func getAliasFilePath() string {
+	return filepath.Join(nodapt_dir, "aliases.json")
+}
+
+// readAliases returns the aliases stored in the nodapt directory, keyed by name.
+func readAliases() (map[string]string, error) {
+	aliases := map[string]string{}
+
+	content, err := os.ReadFile(getAliasFilePath())
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return aliases, nil
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	if err := json.Unmarshal(content, &aliases); err != nil {
+		return nil, errors.WithMessagef(err, "failed to parse %s", getAliasFilePath())
+	}
+
+	return aliases, nil
+}
+
+func writeAliases(aliases map[string]string) error {
+	content, err := json.MarshalIndent(aliases, "", "  ")
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := util.EnsureDir(nodapt_dir); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.WriteFile(getAliasFilePath(), append(content, '\n'), 0644))
+}
+
+// expandAlias returns the constraint of the alias, or the constraint itself if it is not an alias.
+// Aliases may refer to other aliases.
+func expandAlias(constraint string) (string, error) {
+	aliases, err := readAliases()
+
+	if err != nil {
+		return "", err
+	}
+
+	expanded, err := expandAliasIn(aliases, constraint)
+
+	if err != nil {
+		return "", err
+	}
+
+	if expanded != constraint {
+		util.Debug("Expand alias %s to %s\n", constraint, expanded)
+	}
+
+	return expanded, nil
+}
+
+// getDefaultConstraint returns the constraint of the default alias, or nil if there is none.
+func getDefaultConstraint() (*string, error) {
+	aliases, err := readAliases()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := aliases[DefaultAlias]; !ok {
+		return nil, nil
+	}
+
+	constraint, err := expandAlias(DefaultAlias)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &constraint, nil
+}
+
+// SetAlias stores the constraint under the name, replacing the existing alias.
+func SetAlias(name string, constraint string) error {
+	if !aliasNamePattern.MatchString(name) || name == "ls" {
+		return errors.Errorf("invalid alias name %s, it must start with a letter and contain only letters, digits, '_', '.' and '-'", name)
+	}
+
+	// An alias which parses as a constraint would never be used
+	if _, err := semver.NewConstraint(name); err == nil {
+		return errors.Errorf("invalid alias name %s, it is a version constraint", name)
+	}
+
+	aliases, err := readAliases()
+
+	if err != nil {
+		return err
+	}
+
+	aliases[name] = constraint
+
+	// Validate the constraint, which may refer to another alias
+	expanded, err := expandAliasIn(aliases, constraint)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := semver.NewConstraint(expanded); err != nil {
+		return errors.WithMessagef(err, "invalid constraint %s", constraint)
+	}
+
+	if err := writeAliases(aliases); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Alias %s set to %s\n", name, constraint)
+
+	return nil
+}
+
+// expandAliasIn expands the constraint with the given aliases instead of the stored ones.
+func expandAliasIn(aliases map[string]string, constraint string) (string, error) {
+	seen := map[string]bool{}
+
+	for {
+		target, ok := aliases[constraint]
+
+		if !ok {
+			return constraint, nil
+		}
+
+		if seen[constraint] {
+			return "", errors.Errorf("alias %s refers to itself", constraint)
+		}
+
+		seen[constraint] = true
+		constraint = target
+	}
+}
+
+// RemoveAlias removes the alias with the name.
+func RemoveAlias(name string) error {
+	aliases, err := readAliases()
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := aliases[name]; !ok {
+		return errors.Errorf("alias %s does not exist", name)
+	}
+
+	delete(aliases, name)
+
+	if err := writeAliases(aliases); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Alias %s has been removed\n", name)
+
+	return nil
+}
+
+// ListAliases prints all the aliases sorted by name.
+func ListAliases() error {
+	aliases, err := readAliases()
+
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(aliases))
+
+	for name := range aliases {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%s -> %s\n", name, aliases[name])
+	}
+
+	return nil
+}
+
+// Default sets the default constraint used outside of any project, or prints it when constraint is nil.
+func Default(constraint *string) error {
+	if constraint != nil {
+		return SetAlias(DefaultAlias, *constraint)
+	}
+
+	aliases, err := readAliases()
+
+	if err != nil {
+		return err
+	}
+
+	if c, ok := aliases[DefaultAlias]; ok {
+		fmt.Println(c)
+		return nil
+	}
+
+	return errors.New("no default version, set it with 'nodapt default <CONSTRAINT>'")
+}
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlias(t *testing.T) {
+	origNodaptDir := nodapt_dir
+	defer func() { nodapt_dir = origNodaptDir }()
+	nodapt_dir = t.TempDir()
+
+	constraint, err := getDefaultConstraint()
+	assert.Nil(t, err)
+	assert.Nil(t, constraint)
+
+	assert.Nil(t, SetAlias("work-lts", "^18"))
+	assert.Nil(t, SetAlias("default", "work-lts"))
+
+	expanded, err := expandAlias("work-lts")
+	assert.Nil(t, err)
+	assert.Equal(t, "^18", expanded)
+
+	expanded, err = expandAlias("^20")
+	assert.Nil(t, err)
+	assert.Equal(t, "^20", expanded)
+
+	constraint, err = getDefaultConstraint()
+	assert.Nil(t, err)
+	assert.Equal(t, "^18", *constraint)
+
+	assert.NotNil(t, SetAlias("18", "^18"), "a constraint is not a valid name")
+	assert.NotNil(t, SetAlias("ls", "^18"), "ls is reserved")
+	assert.NotNil(t, SetAlias("-lts", "^18"))
+	assert.NotNil(t, SetAlias("broken", "not a constraint"))
+	assert.NotNil(t, SetAlias("work-lts", "default"), "aliases must not refer to themselves")
+
+	expanded, err = expandAlias("work-lts")
+	assert.Nil(t, err)
+	assert.Equal(t, "^18", expanded, "a rejected alias is not stored")
+
+	assert.Nil(t, RemoveAlias("default"))
+	assert.NotNil(t, RemoveAlias("default"))
+
+	constraint, err = getDefaultConstraint()
+	assert.Nil(t, err)
+	assert.Nil(t, constraint)
+}
@@ -0,0 +1,232 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/axetroy/nodapt/internal/version_constraint"
+	"github.com/pkg/errors"
+)
+
+const (
+	FailOnEOL      = "eol"      // Fail when a version is past its end-of-life
+	FailOnSecurity = "security" // Fail when a version has a newer security release
+)
+
+type AuditOptions struct {
+	JSON           bool     // Print the result as JSON
+	FailOn         []string // Return an error for these kinds of problems, FailOnEOL or FailOnSecurity
+	UpdateSchedule bool     // Download the latest release schedule before the audit
+}
+
+// AuditResult is the audit of a node version.
+type AuditResult struct {
+	Version         string  `json:"version"`
+	Source          string  `json:"source"` // "installed", or the file of the project constraint
+	Line            string  `json:"line"`
+	Status          string  `json:"status"`    // The status of the release line, "unknown" if it is not in the schedule
+	EndOfLife       string  `json:"endOfLife"` // The end-of-life date of the release line, empty if unknown
+	EOL             bool    `json:"eol"`
+	SecurityRelease *string `json:"securityRelease"` // The newer security release of the same line
+}
+
+// auditVersion checks the version against the release schedule and the security releases of the index.
+func auditVersion(version string, schedule node.Schedule, versions node.Versions, now time.Time) AuditResult {
+	result := AuditResult{Version: version, Status: "unknown"}
+
+	result.Line, _ = node.ReleaseLineOf(version)
+
+	if line := schedule.Line(version); line != nil {
+		result.Status = line.Status(now)
+		result.EndOfLife = line.End
+		result.EOL = line.IsEOL(now)
+	}
+
+	if release := versions.NewerSecurityRelease(version); release != nil {
+		result.SecurityRelease = &release.Version
+	}
+
+	return result
+}
+
+// The versions already audited by warnAudit, commands running many versions warn once per version
+var (
+	auditedVersions   = map[string]bool{}
+	auditedVersionsMu sync.Mutex
+)
+
+// markAudited records that the version is audited, it reports whether it already was.
+func markAudited(version string) bool {
+	auditedVersionsMu.Lock()
+	defer auditedVersionsMu.Unlock()
+
+	audited := auditedVersions[version]
+	auditedVersions[version] = true
+
+	return audited
+}
+
+// warnAudit prints warnings to stderr when the version is past its end-of-life or has a newer security release.
+// It only reads the release schedule and the index saved by the last fetch, so it works offline.
+func warnAudit(version string) {
+	if util.GetEnvsWithFallback("", "NODAPT_NO_AUDIT") == "1" || markAudited(version) {
+		return
+	}
+
+	schedule, err := node.LoadSchedule(nodapt_dir)
+
+	if err != nil {
+		util.Debug("Warning: failed to load the release schedule: %v\n", err)
+	}
+
+	versions, err := node.GetCachedAllVersions()
+
+	if err != nil {
+		util.Debug("Warning: failed to load the cached index: %v\n", err)
+	}
+
+	result := auditVersion(version, schedule, versions, time.Now())
+
+	if result.EOL {
+		fmt.Fprintf(os.Stderr, "Warning: node %s reached its end-of-life on %s and no longer receives security fixes\n", version, result.EndOfLife)
+	}
+
+	if result.SecurityRelease != nil {
+		fmt.Fprintf(os.Stderr, "Warning: node %s has a newer security release %s, run 'nodapt upgrade %s' to install it\n", version, *result.SecurityRelease, strings.TrimPrefix(result.Line, "v"))
+	}
+
+	if result.EOL || result.SecurityRelease != nil {
+		fmt.Fprintf(os.Stderr, "Set NODAPT_NO_AUDIT=1 to hide these warnings\n")
+	}
+}
+
+// Audit checks the installed versions and the version selected for the project constraint
+// for end-of-life release lines and newer security releases.
+func Audit(options *AuditOptions) error {
+	for _, failOn := range options.FailOn {
+		if failOn != FailOnEOL && failOn != FailOnSecurity {
+			return errors.Errorf("invalid --fail-on value %s, expected %s or %s", failOn, FailOnEOL, FailOnSecurity)
+		}
+	}
+
+	if options.UpdateSchedule {
+		if err := node.UpdateSchedule(nodapt_dir); err != nil {
+			return errors.WithMessage(err, "failed to update the release schedule")
+		}
+
+		fmt.Fprintf(os.Stderr, "Release schedule updated from %s\n", node.NODE_SCHEDULE_URL)
+	}
+
+	schedule, err := node.LoadSchedule(nodapt_dir)
+
+	if err != nil {
+		return err
+	}
+
+	versions, err := node.GetAllVersions()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, the security releases are checked against the cached index\n", err)
+
+		if versions, err = node.GetCachedAllVersions(); err != nil {
+			return err
+		}
+
+		if versions == nil {
+			fmt.Fprintf(os.Stderr, "Warning: no cached index, the security releases are not checked\n")
+		}
+	}
+
+	now := time.Now()
+	results := make([]AuditResult, 0)
+
+	cachedNodes, err := node.GetCachedVersions(nodapt_dir)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, cache := range cachedNodes {
+		result := auditVersion(cache.Version, schedule, versions, now)
+		result.Source = SourceInstalled
+		results = append(results, result)
+	}
+
+	project, err := getProjectConstraint()
+
+	if err != nil {
+		return err
+	}
+
+	// The newest release matching the constraint, an outdated one means the constraint excludes the fixes
+	if project != nil {
+		for _, v := range versions {
+			if ok, err := version_constraint.Match(project.Constraint, v.Version); err != nil {
+				return errors.WithStack(err)
+			} else if ok {
+				result := auditVersion(v.Version, schedule, versions, now)
+				result.Source = project.File
+				results = append(results, result)
+				break
+			}
+		}
+	}
+
+	if options.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(results); err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintf(w, "VERSION\tSOURCE\tSTATUS\tEND OF LIFE\tSECURITY RELEASE\n")
+
+		for _, r := range results {
+			securityRelease := "-"
+
+			if r.SecurityRelease != nil {
+				securityRelease = *r.SecurityRelease
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Version, r.Source, r.Status, orDash(r.EndOfLife), securityRelease)
+		}
+
+		if err := w.Flush(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	eol, insecure := 0, 0
+
+	for _, r := range results {
+		if r.EOL {
+			eol++
+		}
+
+		if r.SecurityRelease != nil {
+			insecure++
+		}
+	}
+
+	for _, failOn := range options.FailOn {
+		if failOn == FailOnEOL && eol > 0 {
+			return errors.Errorf("%d node versions are past their end-of-life", eol)
+		}
+
+		if failOn == FailOnSecurity && insecure > 0 {
+			return errors.Errorf("%d node versions have a newer security release", insecure)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditVersion(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	schedule := node.Schedule{
+		"v16": {Start: "2021-04-20", LTS: "2021-10-26", Maintenance: "2022-10-18", End: "2023-09-11"},
+		"v20": {Start: "2023-04-18", LTS: "2023-10-24", Maintenance: "2024-10-22", End: "2026-04-30"},
+	}
+
+	versions := node.Versions{
+		{Version: "v20.12.0"},
+		{Version: "v20.11.1", Security: true},
+		{Version: "v20.11.0"},
+		{Version: "v16.20.2", Security: true},
+	}
+
+	securityRelease := "v20.11.1"
+
+	assert.Equal(t, AuditResult{
+		Version:         "v20.11.0",
+		Line:            "v20",
+		Status:          "active lts",
+		EndOfLife:       "2026-04-30",
+		SecurityRelease: &securityRelease,
+	}, auditVersion("v20.11.0", schedule, versions, now))
+
+	assert.Equal(t, AuditResult{
+		Version:   "v16.20.2",
+		Line:      "v16",
+		Status:    "eol",
+		EndOfLife: "2023-09-11",
+		EOL:       true,
+	}, auditVersion("v16.20.2", schedule, versions, now))
+
+	assert.Equal(t, AuditResult{
+		Version: "v22.1.0",
+		Line:    "v22",
+		Status:  "unknown",
+	}, auditVersion("v22.1.0", schedule, nil, now), "versions without schedule and index are unknown")
+}
+
+func TestMarkAuditedConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	first := 0
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if !markAudited("v0.0.0-test") {
+				mu.Lock()
+				first++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 1, first, "the version is audited once")
+}
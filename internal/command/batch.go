@@ -0,0 +1,61 @@
+package command
+
+import (
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// batchResult is the result of a process run by runBatch.
+type batchResult struct {
+	ExitCode int           // The exit code, -1 if the process could not be started or was killed by a signal
+	Duration time.Duration // How long the process ran
+	Err      error         // The error of starting or waiting for the process, nil if it exited with 0
+}
+
+// runBatch runs the processes with at most parallel of them at the same time,
+// and returns their results in the order of the processes. A nil process is skipped.
+// When done is not nil, it is called with the result of every process as soon as it exits, possibly concurrently.
+func runBatch(processes []*exec.Cmd, parallel int, done func(i int, result batchResult)) []batchResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]batchResult, len(processes))
+	slots := make(chan struct{}, parallel)
+
+	var wg sync.WaitGroup
+
+	for i, process := range processes {
+		if process == nil {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(i int, process *exec.Cmd) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			start := time.Now()
+			err := startAndWait(process)
+
+			results[i] = batchResult{ExitCode: -1, Duration: time.Since(start), Err: err}
+
+			if process.ProcessState != nil {
+				results[i].ExitCode = process.ProcessState.ExitCode()
+			}
+
+			if done != nil {
+				done(i, results[i])
+			}
+		}(i, process)
+	}
+
+	wg.Wait()
+
+	return results
+}
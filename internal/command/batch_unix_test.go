@@ -0,0 +1,57 @@
+//go:build unix
+
+package command
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBatch(t *testing.T) {
+	processes := []*exec.Cmd{
+		exec.Command("sh", "-c", "sleep 0.2; exit 3"),
+		nil,
+		exec.Command("sh", "-c", "sleep 0.2"),
+		exec.Command("sh", "-c", "sleep 0.2"),
+	}
+
+	start := time.Now()
+	results := runBatch(processes, 3, nil)
+	elapsed := time.Since(start)
+
+	assert.Len(t, results, 4)
+
+	assert.Error(t, results[0].Err)
+	assert.Equal(t, 3, results[0].ExitCode)
+
+	assert.Equal(t, batchResult{}, results[1])
+
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, 0, results[2].ExitCode)
+	assert.GreaterOrEqual(t, results[2].Duration, 200*time.Millisecond)
+
+	// The three processes run at the same time
+	assert.Less(t, elapsed, 550*time.Millisecond)
+}
+
+func TestRunBatchLimitsParallelism(t *testing.T) {
+	processes := make([]*exec.Cmd, 6)
+
+	for i := range processes {
+		processes[i] = exec.Command("sh", "-c", "sleep 0.1")
+	}
+
+	start := time.Now()
+	results := runBatch(processes, 2, nil)
+	elapsed := time.Since(start)
+
+	for _, r := range results {
+		assert.NoError(t, r.Err)
+	}
+
+	// Six processes two at a time take at least three rounds
+	assert.GreaterOrEqual(t, elapsed, 300*time.Millisecond)
+}
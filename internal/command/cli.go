@@ -5,18 +5,32 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/axetroy/nodapt/internal/node"
 	"github.com/axetroy/nodapt/internal/util"
 )
 
 var nodapt_dir string
 
+// Fail instead of resolving the constraint again when the lockfile is missing or outdated
+var frozen_lockfile bool
+
+// SetFrozenLockfile makes the lockfile of the project required and up to date.
+func SetFrozenLockfile(frozen bool) {
+	frozen_lockfile = frozen
+}
+
 func init() {
+	nodapt_dir = getNodaptDir()
+
+	// Keep the last fetched index, so the security releases are known without network
+	node.IndexCacheFile = filepath.Join(nodapt_dir, "index.json")
+}
 
+func getNodaptDir() string {
 	nodaptDirFromEnv := util.GetEnvsWithFallback("", "NODE_ENV_DIR")
 
 	if nodaptDirFromEnv != "" {
-		nodapt_dir = nodaptDirFromEnv
-		return
+		return nodaptDirFromEnv
 	}
 
 	homeDir, err := os.UserHomeDir()
@@ -25,9 +39,8 @@ func init() {
 		// Fallback to temporary directory if home directory is not available
 		fmt.Fprintf(os.Stderr, "Warning: Unable to get user home directory: %v\n", err)
 		fmt.Fprintf(os.Stderr, "Using temporary directory as fallback\n")
-		nodapt_dir = filepath.Join(os.TempDir(), ".nodapt")
-		return
+		return filepath.Join(os.TempDir(), ".nodapt")
 	}
 
-	nodapt_dir = filepath.Join(homeDir, ".nodapt")
+	return filepath.Join(homeDir, ".nodapt")
 }
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+type CurrentOptions struct {
+	Install bool // Install the selected version if it is not installed yet
+}
+
+// Current prints the node version that run uses in the current working directory and why.
+func Current(options *CurrentOptions) error {
+	project, resolution, err := resolveProject(options.Install)
+
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+
+	switch resolution.Source {
+	case SourceSystem:
+		if resolution.Version != "" {
+			fmt.Fprintf(w, "Version:\tsystem (%s)\n", resolution.Version)
+		} else {
+			fmt.Fprintf(w, "Version:\tsystem (node not found in PATH)\n")
+		}
+	case SourceInstalled:
+		fmt.Fprintf(w, "Version:\t%s\n", resolution.Version)
+		fmt.Fprintf(w, "Path:\t%s\n", resolution.Path)
+	case SourceRemote:
+		fmt.Fprintf(w, "Version:\t%s (not installed)\n", resolution.Version)
+	}
+
+	if project != nil {
+		fmt.Fprintf(w, "Constraint:\t%s\n", project.Constraint)
+		if project.Npm != "" {
+			fmt.Fprintf(w, "Npm:\t%s\n", project.Npm)
+		}
+		if project.Field != "" {
+			fmt.Fprintf(w, "From:\t%s (%s)\n", project.File, project.Field)
+		} else {
+			fmt.Fprintf(w, "From:\t%s\n", project.File)
+		}
+	} else {
+		fmt.Fprintf(w, "Constraint:\tnone\n")
+	}
+
+	return errors.WithStack(w.Flush())
+}
@@ -0,0 +1,373 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/shell"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Diagnostic is the result of a single doctor check.
+type Diagnostic struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"` // How to fix the problem, empty when the check passed
+}
+
+type DoctorOptions struct {
+	JSON bool // Print the result as JSON
+}
+
+// checkMirror checks that the mirror is reachable and its index is up to date.
+func checkMirror() []Diagnostic {
+	mirrorSource := "default"
+
+	if util.GetEnvsWithFallback("", "NODE_MIRROR") != "" {
+		mirrorSource = "set by NODE_MIRROR"
+	} else if util.IsSimplifiedChinese() {
+		mirrorSource = "chosen by the Simplified Chinese locale or timezone"
+	}
+
+	result := []Diagnostic{{
+		Name:    "mirror",
+		Status:  StatusPass,
+		Message: fmt.Sprintf("Using %s (%s)", node.NODE_MIRROR, mirrorSource),
+	}}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	start := time.Now()
+
+	resp, err := client.Get(node.NODE_MIRROR + "index.json")
+
+	if err != nil {
+		return append(result, Diagnostic{
+			Name:    "mirror reachability",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("Cannot reach %s: %v", node.NODE_MIRROR, err),
+			Hint:    "Check your network and proxy, or set NODE_MIRROR to a reachable mirror",
+		})
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return append(result, Diagnostic{
+			Name:    "mirror reachability",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("%sindex.json responded with status code %d", node.NODE_MIRROR, resp.StatusCode),
+			Hint:    "Make sure NODE_MIRROR points to the root of a Node.js distribution mirror and ends with '/'",
+		})
+	}
+
+	var versions node.Versions
+
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return append(result, Diagnostic{
+			Name:    "mirror reachability",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("Invalid index.json from %s: %v", node.NODE_MIRROR, err),
+			Hint:    "Make sure NODE_MIRROR points to the root of a Node.js distribution mirror",
+		})
+	}
+
+	result = append(result, Diagnostic{
+		Name:    "mirror reachability",
+		Status:  StatusPass,
+		Message: fmt.Sprintf("index.json fetched in %s", time.Since(start).Round(time.Millisecond)),
+	})
+
+	return append(result, checkIndexFreshness(versions, time.Now()))
+}
+
+// checkIndexFreshness warns when the newest release of the index is too old, which usually means an outdated mirror.
+func checkIndexFreshness(versions node.Versions, now time.Time) Diagnostic {
+	const maxAge = 60 * 24 * time.Hour
+
+	if len(versions) == 0 {
+		return Diagnostic{
+			Name:    "index freshness",
+			Status:  StatusFail,
+			Message: "The index does not contain any release",
+			Hint:    "Set NODE_MIRROR to another mirror",
+		}
+	}
+
+	latest, err := time.Parse(time.DateOnly, versions[0].Date)
+
+	if err != nil {
+		return Diagnostic{
+			Name:    "index freshness",
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("Cannot parse the release date %q of %s", versions[0].Date, versions[0].Version),
+		}
+	}
+
+	if now.Sub(latest) > maxAge {
+		return Diagnostic{
+			Name:    "index freshness",
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("The newest release %s is from %s, the mirror may be out of sync", versions[0].Version, versions[0].Date),
+			Hint:    "Set NODE_MIRROR to a mirror which is kept up to date",
+		}
+	}
+
+	return Diagnostic{
+		Name:    "index freshness",
+		Status:  StatusPass,
+		Message: fmt.Sprintf("The newest release is %s from %s", versions[0].Version, versions[0].Date),
+	}
+}
+
+// checkNodaptDir checks that the nodapt directory is writable.
+func checkNodaptDir() Diagnostic {
+	if err := util.EnsureDir(nodapt_dir); err != nil {
+		return Diagnostic{
+			Name:    "nodapt directory",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("Cannot create %s: %v", nodapt_dir, err),
+			Hint:    "Set NODE_ENV_DIR to a writable directory",
+		}
+	}
+
+	file, err := os.CreateTemp(nodapt_dir, ".doctor-*")
+
+	if err != nil {
+		return Diagnostic{
+			Name:    "nodapt directory",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("%s is not writable: %v", nodapt_dir, err),
+			Hint:    "Fix the permissions of the directory or set NODE_ENV_DIR to a writable directory",
+		}
+	}
+
+	file.Close()
+	_ = os.Remove(file.Name())
+
+	return Diagnostic{
+		Name:    "nodapt directory",
+		Status:  StatusPass,
+		Message: fmt.Sprintf("%s is writable", nodapt_dir),
+	}
+}
+
+// checkCache checks that every installed version is complete and no download was left behind.
+func checkCache() []Diagnostic {
+	cachedNodes, err := node.GetCachedVersions(nodapt_dir)
+
+	if err != nil {
+		return []Diagnostic{{
+			Name:    "cache integrity",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("Cannot read the installed versions: %v", err),
+			Hint:    fmt.Sprintf("Remove %s and let nodapt install the versions again", filepath.Join(nodapt_dir, "node")),
+		}}
+	}
+
+	result := make([]Diagnostic, 0)
+	broken := 0
+
+	for _, cache := range cachedNodes {
+		ok, err := util.FindExecutable(getBinaryDir(cache.FilePath), "node")
+
+		if err != nil || !ok {
+			broken++
+			result = append(result, Diagnostic{
+				Name:    "cache integrity",
+				Status:  StatusFail,
+				Message: fmt.Sprintf("node %s in %s is incomplete, the node executable is missing", cache.Version, cache.FilePath),
+				Hint:    fmt.Sprintf("Run 'nodapt rm %s' and let nodapt install it again", cache.Version),
+			})
+		}
+	}
+
+	if broken == 0 {
+		result = append(result, Diagnostic{
+			Name:    "cache integrity",
+			Status:  StatusPass,
+			Message: fmt.Sprintf("%d installed version(s) are complete", len(cachedNodes)),
+		})
+	}
+
+	// Successful downloads are removed after extraction, leftovers come from interrupted installs
+	if entries, err := os.ReadDir(filepath.Join(nodapt_dir, "download")); err == nil && len(entries) > 0 {
+		names := make([]string, 0, len(entries))
+
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+
+		result = append(result, Diagnostic{
+			Name:    "cache integrity",
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("Leftover downloads from interrupted installs: %s", strings.Join(names, ", ")),
+			Hint:    fmt.Sprintf("Remove %s", filepath.Join(nodapt_dir, "download")),
+		})
+	}
+
+	return result
+}
+
+// versionManagers are the other version managers whose shims may shadow the node selected by nodapt.
+var versionManagers = []struct {
+	name    string
+	envs    []string
+	pattern string // A fragment of the directories they add to PATH
+}{
+	{"nvm", []string{"NVM_DIR", "NVM_BIN"}, ".nvm"},
+	{"fnm", []string{"FNM_MULTISHELL_PATH", "FNM_DIR"}, "fnm_multishells"},
+	{"volta", []string{"VOLTA_HOME"}, ".volta"},
+	{"asdf", []string{"ASDF_DIR", "ASDF_DATA_DIR"}, ".asdf"},
+	{"n", []string{"N_PREFIX"}, ""},
+	{"nodenv", []string{"NODENV_ROOT"}, ".nodenv"},
+}
+
+// shadowingExecutables are the executables which nodapt does not provide itself or which other tools install as shims,
+// they are resolved from the remaining PATH entries and may run another node.
+var shadowingExecutables = []string{"npm", "npx", "corepack", "yarn", "pnpm"}
+
+// checkPathShadowing checks the directories that remain in PATH after nodapt removed the ones containing node.
+// Directories with package manager shims but no node binary are not removed and may run another node.
+func checkPathShadowing(paths string, getenv func(string) string) []Diagnostic {
+	const marker = "__nodapt_bin__"
+
+	result := make([]Diagnostic, 0)
+
+	remaining := filepath.SplitList(util.AppendEnvPath(paths, marker))
+
+	for _, dir := range remaining {
+		if dir == marker || dir == "" {
+			continue
+		}
+
+		found := make([]string, 0)
+
+		for _, name := range shadowingExecutables {
+			if p, err := util.NewEnv([]string{"PATH=" + dir}).LookPath(name); err == nil {
+				found = append(found, filepath.Base(p))
+			}
+		}
+
+		if len(found) > 0 {
+			result = append(result, Diagnostic{
+				Name:    "PATH shadowing",
+				Status:  StatusWarn,
+				Message: fmt.Sprintf("%s stays in PATH and provides %s", dir, strings.Join(found, ", ")),
+				Hint:    "These are found when the selected node does not provide them, install them with the selected node or use corepack",
+			})
+		}
+	}
+
+	for _, manager := range versionManagers {
+		detected := false
+
+		for _, env := range manager.envs {
+			if getenv(env) != "" {
+				detected = true
+			}
+		}
+
+		for _, dir := range filepath.SplitList(paths) {
+			if manager.pattern != "" && strings.Contains(filepath.ToSlash(dir), manager.pattern) {
+				detected = true
+			}
+		}
+
+		if detected {
+			result = append(result, Diagnostic{
+				Name:    "version managers",
+				Status:  StatusWarn,
+				Message: fmt.Sprintf("%s is active in this shell", manager.name),
+				Hint:    fmt.Sprintf("Shims or hooks of %s may switch node again inside 'nodapt use', disable its shell integration if you see unexpected versions", manager.name),
+			})
+		}
+	}
+
+	if len(result) == 0 {
+		result = append(result, Diagnostic{
+			Name:    "PATH shadowing",
+			Status:  StatusPass,
+			Message: "No other node installation or shim remains in PATH",
+		})
+	}
+
+	return result
+}
+
+// checkShell checks that the shell used by 'nodapt use' can be detected.
+func checkShell() Diagnostic {
+	shellPath, err := shell.GetPath()
+
+	if err != nil {
+		return Diagnostic{
+			Name:    "shell detection",
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("Cannot detect the shell: %v", err),
+			Hint:    "Set the SHELL environment variable, run with DEBUG=1 to see the detection chain",
+		}
+	}
+
+	return Diagnostic{
+		Name:    "shell detection",
+		Status:  StatusPass,
+		Message: fmt.Sprintf("'nodapt use' starts %s (%s/%s)", shellPath, runtime.GOOS, runtime.GOARCH),
+	}
+}
+
+// Doctor checks the environment for common problems and prints the result with remediation hints.
+// It returns an error if any check failed.
+func Doctor(options *DoctorOptions) error {
+	diagnostics := make([]Diagnostic, 0)
+
+	diagnostics = append(diagnostics, checkMirror()...)
+	diagnostics = append(diagnostics, checkNodaptDir())
+	diagnostics = append(diagnostics, checkCache()...)
+	diagnostics = append(diagnostics, checkPathShadowing(os.Getenv("PATH"), os.Getenv)...)
+	diagnostics = append(diagnostics, checkShell())
+
+	failed := 0
+
+	for _, d := range diagnostics {
+		if d.Status == StatusFail {
+			failed++
+		}
+	}
+
+	if options.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(diagnostics); err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		for _, d := range diagnostics {
+			fmt.Printf("[%s] %s: %s\n", strings.ToUpper(d.Status), d.Name, d.Message)
+
+			if d.Hint != "" {
+				fmt.Printf("       hint: %s\n", d.Hint)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("%d check(s) failed", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIndexFreshness(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, StatusPass, checkIndexFreshness(node.Versions{{Version: "v22.2.0", Date: "2024-05-15"}}, now).Status)
+	assert.Equal(t, StatusWarn, checkIndexFreshness(node.Versions{{Version: "v21.0.0", Date: "2023-10-17"}}, now).Status)
+	assert.Equal(t, StatusWarn, checkIndexFreshness(node.Versions{{Version: "v21.0.0", Date: "unknown"}}, now).Status)
+	assert.Equal(t, StatusFail, checkIndexFreshness(node.Versions{}, now).Status)
+}
+
+func TestCheckPathShadowing(t *testing.T) {
+	root := t.TempDir()
+
+	ext := ""
+
+	if runtime.GOOS == "windows" {
+		ext = ".exe"
+	}
+
+	nodeDir := filepath.Join(root, "node")
+	shimDir := filepath.Join(root, "shim")
+	cleanDir := filepath.Join(root, "clean")
+
+	for _, dir := range []string{nodeDir, shimDir, cleanDir} {
+		_ = os.MkdirAll(dir, 0755)
+	}
+
+	_ = os.WriteFile(filepath.Join(nodeDir, "node"+ext), []byte(""), 0755)
+	_ = os.WriteFile(filepath.Join(nodeDir, "npm"+ext), []byte(""), 0755)
+	_ = os.WriteFile(filepath.Join(shimDir, "pnpm"+ext), []byte(""), 0755)
+
+	paths := strings.Join([]string{nodeDir, shimDir, cleanDir}, string(os.PathListSeparator))
+
+	t.Run("shims without node", func(t *testing.T) {
+		result := checkPathShadowing(paths, func(string) string { return "" })
+
+		assert.Len(t, result, 1)
+		assert.Equal(t, StatusWarn, result[0].Status)
+		assert.Contains(t, result[0].Message, shimDir)
+		assert.Contains(t, result[0].Message, "pnpm")
+	})
+
+	t.Run("version manager", func(t *testing.T) {
+		result := checkPathShadowing(cleanDir, func(key string) string {
+			if key == "VOLTA_HOME" {
+				return "/home/user/.volta"
+			}
+			return ""
+		})
+
+		assert.Len(t, result, 1)
+		assert.Equal(t, "version managers", result[0].Name)
+		assert.Contains(t, result[0].Message, "volta")
+	})
+
+	t.Run("clean", func(t *testing.T) {
+		result := checkPathShadowing(cleanDir, func(string) string { return "" })
+
+		assert.Len(t, result, 1)
+		assert.Equal(t, StatusPass, result[0].Status)
+	})
+}
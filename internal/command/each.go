@@ -0,0 +1,205 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/axetroy/nodapt/internal/workspace"
+	"github.com/pkg/errors"
+)
+
+type EachOptions struct {
+	Filter   string   // Only the packages whose name or path relative to the workspace root matches the glob
+	Parallel int      // How many packages run the command at the same time
+	Cmd      []string // The command to run in every package
+}
+
+// eachPackage is a package of the workspace that each runs the command in.
+type eachPackage struct {
+	Name    string // The name of package.json, or the path relative to the workspace root without one
+	Dir     string
+	Version string // The node used, "system" for the node in PATH
+}
+
+// Each runs the command in every package of the workspace of the current working directory,
+// with the node selected by the constraint of the package. The versions are installed before running anything,
+// the output lines are prefixed with the package name, and a summary is printed at the end.
+// It fails if the command fails in any package.
+func Each(options *EachOptions) error {
+	if len(options.Cmd) == 0 {
+		return errors.New("no command provided")
+	}
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ws, err := workspace.Find(cwd)
+
+	if err != nil {
+		return err
+	}
+
+	if ws == nil {
+		return errors.New("no workspace found, declare workspaces in package.json or pnpm-workspace.yaml")
+	}
+
+	packages, err := listEachPackages(ws, options.Filter)
+
+	if err != nil {
+		return err
+	}
+
+	if len(packages) == 0 {
+		if options.Filter != "" {
+			return errors.Errorf("no package of the workspace %s matches %s", ws.Root, options.Filter)
+		}
+
+		return errors.Errorf("no package found in the workspace %s", ws.Root)
+	}
+
+	width := 0
+
+	for _, pkg := range packages {
+		width = max(width, len(pkg.Name))
+	}
+
+	var mu sync.Mutex
+
+	processes := make([]*exec.Cmd, len(packages))
+	writers := make([]*util.PrefixWriter, 0, len(packages)*2)
+	errs := make([]error, len(packages))
+
+	// Prepare every package first, so each version is installed once before the commands run
+	for i := range packages {
+		pkg := &packages[i]
+
+		process, err := prepareEachPackage(pkg, options.Cmd)
+
+		if err != nil {
+			errs[i] = err
+			fmt.Fprintf(os.Stderr, "%-*s | %v\n", width, pkg.Name, err)
+			continue
+		}
+
+		prefix := fmt.Sprintf("%-*s | ", width, pkg.Name)
+		stdout := util.NewPrefixWriter(os.Stdout, prefix, &mu)
+		stderr := util.NewPrefixWriter(os.Stderr, prefix, &mu)
+
+		process.Stdout = stdout
+		process.Stderr = stderr
+
+		writers = append(writers, stdout, stderr)
+		processes[i] = process
+	}
+
+	results := runBatch(processes, options.Parallel, nil)
+
+	for _, w := range writers {
+		_ = w.Flush()
+	}
+
+	failed := 0
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "\nPACKAGE\tNODE\tRESULT\tDURATION\n")
+
+	for i, pkg := range packages {
+		result, duration := "ok", results[i].Duration.Round(time.Millisecond).String()
+
+		if errs[i] != nil {
+			result, duration = "error", "-"
+		} else if results[i].Err != nil {
+			result = fmt.Sprintf("failed (exit code %d)", results[i].ExitCode)
+		}
+
+		if result != "ok" {
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pkg.Name, orDash(pkg.Version), result, duration)
+	}
+
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if failed > 0 {
+		return errors.Errorf("%d of %d packages failed", failed, len(packages))
+	}
+
+	return nil
+}
+
+// listEachPackages returns the packages of the workspace whose name or relative path matches the filter, if any.
+func listEachPackages(ws *workspace.Workspace, filter string) ([]eachPackage, error) {
+	dirs, err := ws.Packages()
+
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make([]eachPackage, 0, len(dirs))
+
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(ws.Root, dir)
+
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		name := readPackageManifest(dir).Name
+
+		if filter != "" && !workspace.MatchGlob(filter, rel) && (name == "" || !workspace.MatchGlob(filter, name)) {
+			continue
+		}
+
+		if name == "" {
+			name = rel
+		}
+
+		packages = append(packages, eachPackage{Name: name, Dir: dir})
+	}
+
+	return packages, nil
+}
+
+// prepareEachPackage resolves the node of the package, installs it if needed,
+// and returns the process of the command in the package directory.
+func prepareEachPackage(pkg *eachPackage, cmd []string) (*exec.Cmd, error) {
+	_, resolution, err := resolveProjectIn(pkg.Dir, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	binDirs, err := getPackageManagerBinDirs(pkg.Dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options := &RunOptions{Cmd: cmd, Dir: pkg.Dir, BinDirs: binDirs}
+
+	if resolution.Source == SourceSystem {
+		pkg.Version = strings.TrimSpace("system " + resolution.Version)
+	} else {
+		pkg.Version = resolution.Version
+		options.Version = resolution.Version
+		options.SHA256 = resolution.SHA256
+	}
+
+	return newProcess(options)
+}
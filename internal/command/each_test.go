@@ -0,0 +1,56 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/axetroy/nodapt/internal/workspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListEachPackages(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+
+	for name, content := range map[string]string{
+		"package.json":              `{"workspaces": ["packages/*", "apps/*"]}`,
+		"packages/a/package.json":   `{"name": "@scope/a"}`,
+		"packages/b/package.json":   `{"name": "@scope/b"}`,
+		"apps/web/package.json":     `{"name": "web"}`,
+		"apps/unnamed/package.json": `{}`,
+	} {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	ws, err := workspace.Find(dir)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		filter   string
+		expected []string
+	}{
+		{filter: "", expected: []string{"apps/unnamed", "web", "@scope/a", "@scope/b"}},
+		{filter: "@scope/*", expected: []string{"@scope/a", "@scope/b"}},
+		{filter: "apps/*", expected: []string{"apps/unnamed", "web"}},
+		{filter: "web", expected: []string{"web"}},
+		{filter: "packages/b", expected: []string{"@scope/b"}},
+		{filter: "nothing", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			packages, err := listEachPackages(ws, tt.filter)
+			assert.NoError(t, err)
+
+			names := make([]string, 0, len(packages))
+			for _, pkg := range packages {
+				names = append(names, pkg.Name)
+			}
+
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/axetroy/nodapt/internal/version_constraint"
+	"github.com/pkg/errors"
+)
+
+// engineCommands maps the executables to the entry of engines in package.json that constrains them.
+var engineCommands = map[string]string{
+	"npm":  "npm",
+	"npx":  "npm",
+	"pnpm": "pnpm",
+	"pnpx": "pnpm",
+	"yarn": "yarn",
+}
+
+// matchNpm reports whether the npm version satisfies the constraint, an unknown version never does.
+func matchNpm(constraint string, version string) bool {
+	if version == "" {
+		return false
+	}
+
+	ok, err := version_constraint.Match(constraint, version)
+
+	if err != nil {
+		util.Debug("Failed to match npm %s with %s: %v\n", version, constraint, err)
+		return false
+	}
+
+	return ok
+}
+
+// getIndexNpmVersion returns the version of the npm bundled with the node version according to the index of the mirror,
+// read from the cache if possible. It returns an empty string if it is unknown.
+func getIndexNpmVersion(version string) string {
+	versions, err := node.GetCachedAllVersions()
+
+	if err != nil {
+		util.Debug("Warning: %v\n", err)
+	}
+
+	if npm := findIndexNpmVersion(versions, version); npm != "" {
+		return npm
+	}
+
+	if versions, err = node.GetAllVersions(); err != nil {
+		util.Debug("Warning: %v\n", err)
+		return ""
+	}
+
+	return findIndexNpmVersion(versions, version)
+}
+
+// findIndexNpmVersion returns the npm field of the version in the index, or an empty string if the version is not in it.
+func findIndexNpmVersion(versions node.Versions, version string) string {
+	version = strings.TrimPrefix(version, "v")
+
+	for _, v := range versions {
+		if strings.TrimPrefix(v.Version, "v") == version {
+			return v.Npm
+		}
+	}
+
+	return ""
+}
+
+// getBundledNpmVersion returns the version of the npm installed with the node in nodeEnvPath.
+func getBundledNpmVersion(nodeEnvPath string) string {
+	return readPackageVersion(filepath.Join(getGlobalModulesDir(nodeEnvPath), "npm"))
+}
+
+// findCachedVersionWithNpm is like findCachedVersion, but only returns a version whose npm satisfies npmConstraint when it is not empty.
+func findCachedVersionWithNpm(cachedNodes []node.CachedNode, constraint string, npmConstraint string) (*node.CachedNode, error) {
+	if npmConstraint == "" {
+		return findCachedVersion(cachedNodes, constraint)
+	}
+
+	sorted := make([]node.CachedNode, len(cachedNodes))
+	copy(sorted, cachedNodes)
+
+	// Sort versions in descending order
+	sort.Sort(sort.Reverse(node.ByVersion(sorted)))
+
+	for _, cache := range sorted {
+		if ok, err := version_constraint.Match(constraint, cache.Version); err != nil {
+			return nil, errors.WithStack(err)
+		} else if ok && matchNpm(npmConstraint, getBundledNpmVersion(cache.FilePath)) {
+			return &cache, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// findRemoteVersion returns the newest version of the index which satisfies the constraint,
+// and whose bundled npm satisfies npmConstraint when it is not empty. It returns nil if there is none.
+func findRemoteVersion(versions node.Versions, constraint string, npmConstraint string) (*node.Version, error) {
+	for _, version := range versions {
+		if ok, err := version_constraint.Match(constraint, version.Version); err != nil {
+			return nil, errors.WithMessagef(err, "failed to match version %s with constraint %s", version.Version, constraint)
+		} else if ok && (npmConstraint == "" || matchNpm(npmConstraint, version.Npm)) {
+			return &version, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// getPackageEngines returns the engines of the nearest package.json of dir and its path, or of the workspace root
+// when it has none. It returns nil if there is no package.json or it has no engines.
+func getPackageEngines(dir string) (*node.PackageJSONEngine, string, error) {
+	dir, err := getWorkingDir(dir)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	packageJSONPath := util.LoopUpFile(dir, "package.json")
+
+	if packageJSONPath == nil {
+		return nil, "", nil
+	}
+
+	engines, err := node.GetEnginesFromPackageJSON(*packageJSONPath)
+
+	if err != nil {
+		return nil, "", errors.WithMessagef(err, "failed to get engines from %s", *packageJSONPath)
+	}
+
+	if engines != nil {
+		return engines, *packageJSONPath, nil
+	}
+
+	// A package of a workspace without engines follows the ones of the workspace root
+	rootPackageJSONPath, err := getWorkspaceRootPackageJSON(*packageJSONPath)
+
+	if err != nil || rootPackageJSONPath == "" {
+		return nil, "", err
+	}
+
+	engines, err = node.GetEnginesFromPackageJSON(rootPackageJSONPath)
+
+	if err != nil {
+		return nil, "", errors.WithMessagef(err, "failed to get engines from %s", rootPackageJSONPath)
+	}
+
+	return engines, rootPackageJSONPath, nil
+}
+
+// getPackageManagerVersion returns the version of the package manager at commandPath.
+// It is read from the package.json of an install of nodapt or npm when possible, so no process is started,
+// otherwise it runs the command with --version. It returns an empty string if it is unknown.
+func getPackageManagerVersion(name string, commandPath string, env *util.Env) string {
+	binDir := filepath.Dir(commandPath)
+
+	for _, packageDir := range []string{
+		filepath.Join(binDir, "..", "package"),                   // Installed for packageManager
+		filepath.Join(binDir, "..", "lib", "node_modules", name), // Installed globally or with node
+		filepath.Join(binDir, "node_modules", name),              // Installed globally or with node on Windows
+	} {
+		manifest := readPackageManifest(packageDir)
+
+		if manifest.Version != "" && (manifest.Name == name || (name == "yarn" && manifest.Name == "@yarnpkg/cli-dist")) {
+			return manifest.Version
+		}
+	}
+
+	process := exec.Command(commandPath, "--version")
+	process.Env = env.Environ()
+
+	output, err := process.Output()
+
+	if err != nil {
+		util.Debug("Failed to get the version of %s: %v\n", commandPath, err)
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+// checkEngines warns when the command is a package manager whose version, as found in the PATH of env,
+// does not satisfy the engines of the nearest package.json of dir. The command runs anyway.
+func checkEngines(cmd []string, dir string, env *util.Env) {
+	if len(cmd) == 0 {
+		return
+	}
+
+	command := filepath.Base(cmd[0])
+	command = strings.TrimSuffix(command, filepath.Ext(command))
+
+	name, ok := engineCommands[command]
+
+	if !ok {
+		return
+	}
+
+	engines, packageJSONPath, err := getPackageEngines(dir)
+
+	if err != nil {
+		util.Debug("Warning: %v\n", err)
+		return
+	}
+
+	constraint := engines.Get(name)
+
+	if constraint == nil {
+		return
+	}
+
+	commandPath, err := env.LookPath(name)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s is required by engines.%s %s of %s, but it is not found\n", name, name, *constraint, packageJSONPath)
+		return
+	}
+
+	version := getPackageManagerVersion(name, commandPath, env)
+
+	if version == "" {
+		return
+	}
+
+	if ok, err := version_constraint.Match(*constraint, version); err != nil {
+		util.Debug("Failed to match %s %s with %s: %v\n", name, version, *constraint, err)
+	} else if !ok {
+		hint := ""
+
+		if name != "npm" {
+			hint = ", set packageManager to a matching version to install it"
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: %s %s does not satisfy engines.%s %s of %s%s\n", name, version, name, *constraint, packageJSONPath, hint)
+	}
+}
@@ -0,0 +1,147 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRemoteVersion(t *testing.T) {
+	versions := node.Versions{
+		{Version: "v22.1.0", Npm: "10.7.0"},
+		{Version: "v20.12.0", Npm: "10.5.0"},
+		{Version: "v20.5.0", Npm: "9.8.0"},
+		{Version: "v18.20.0", Npm: "10.5.0"},
+		{Version: "v18.10.0", Npm: "8.19.2"},
+		{Version: "v0.10.0"},
+	}
+
+	tests := []struct {
+		name          string
+		constraint    string
+		npmConstraint string
+		expected      string
+	}{
+		{name: "Without npm constraint", constraint: "^20", expected: "v20.12.0"},
+		{name: "Newest version bundles matching npm", constraint: "^20", npmConstraint: ">=10", expected: "v20.12.0"},
+		{name: "Older version bundles matching npm", constraint: "^20", npmConstraint: "^9", expected: "v20.5.0"},
+		{name: "Across majors", constraint: ">=18", npmConstraint: "^8", expected: "v18.10.0"},
+		{name: "No version bundles matching npm", constraint: "^20", npmConstraint: "^11", expected: ""},
+		{name: "Unknown npm never matches", constraint: "0.10.x", npmConstraint: "*", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findRemoteVersion(versions, tt.constraint, tt.npmConstraint)
+
+			assert.NoError(t, err)
+
+			if tt.expected == "" {
+				assert.Nil(t, got)
+			} else if assert.NotNil(t, got) {
+				assert.Equal(t, tt.expected, got.Version)
+			}
+		})
+	}
+}
+
+func TestFindCachedVersionWithNpm(t *testing.T) {
+	dir := t.TempDir()
+
+	install := func(version string, npm string) node.CachedNode {
+		nodeEnvPath := filepath.Join(dir, "node-"+version)
+		npmDir := filepath.Join(getGlobalModulesDir(nodeEnvPath), "npm")
+
+		assert.NoError(t, os.MkdirAll(npmDir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(npmDir, "package.json"), []byte(`{"name": "npm", "version": "`+npm+`"}`), 0644))
+
+		return node.CachedNode{Version: version, FilePath: nodeEnvPath}
+	}
+
+	cached := []node.CachedNode{
+		install("v20.5.0", "9.8.0"),
+		install("v20.12.0", "10.5.0"),
+		install("v18.20.0", "10.5.0"),
+	}
+
+	tests := []struct {
+		name          string
+		constraint    string
+		npmConstraint string
+		expected      string
+	}{
+		{name: "Without npm constraint", constraint: "^20", expected: "v20.12.0"},
+		{name: "Prefer matching npm", constraint: "^20", npmConstraint: "^9", expected: "v20.5.0"},
+		{name: "No installed version bundles matching npm", constraint: "^20", npmConstraint: "^8", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findCachedVersionWithNpm(cached, tt.constraint, tt.npmConstraint)
+
+			assert.NoError(t, err)
+
+			if tt.expected == "" {
+				assert.Nil(t, got)
+			} else if assert.NotNil(t, got) {
+				assert.Equal(t, tt.expected, got.Version)
+			}
+		})
+	}
+}
+
+func TestFindIndexNpmVersion(t *testing.T) {
+	versions := node.Versions{
+		{Version: "v20.12.0", Npm: "10.5.0"},
+		{Version: "v18.20.0", Npm: "10.5.0"},
+		{Version: "v18.10.0", Npm: "8.19.2"},
+	}
+
+	tests := []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{name: "With v prefix", version: "v18.10.0", expected: "8.19.2"},
+		{name: "Without v prefix", version: "20.12.0", expected: "10.5.0"},
+		{name: "Not in the index", version: "v16.0.0", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, findIndexNpmVersion(versions, tt.version))
+		})
+	}
+}
+
+func TestGetPackageManagerVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		pm         string
+		packageDir string
+		manifest   string
+		expected   string
+	}{
+		{name: "Installed for packageManager", pm: "pnpm", packageDir: "package", manifest: `{"name": "pnpm", "version": "9.1.0"}`, expected: "9.1.0"},
+		{name: "Yarn bundle installed for packageManager", pm: "yarn", packageDir: "package", manifest: `{"name": "@yarnpkg/cli-dist", "version": "4.1.0"}`, expected: "4.1.0"},
+		{name: "Installed with node", pm: "npm", packageDir: filepath.Join("lib", "node_modules", "npm"), manifest: `{"name": "npm", "version": "10.5.0"}`, expected: "10.5.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			packageDir := filepath.Join(dir, tt.packageDir)
+
+			assert.NoError(t, os.MkdirAll(packageDir, 0755))
+			assert.NoError(t, os.WriteFile(filepath.Join(packageDir, "package.json"), []byte(tt.manifest), 0644))
+
+			commandPath := filepath.Join(dir, "bin", tt.pm)
+
+			assert.Equal(t, tt.expected, getPackageManagerVersion(tt.pm, commandPath, util.NewEnv(nil)))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/axetroy/nodapt/internal/util"
+)
+
+// getBinaryDir returns the directory containing the node executable of the Node.js installed in nodeEnvPath.
+func getBinaryDir(nodeEnvPath string) string {
+	if runtime.GOOS == "windows" {
+		return nodeEnvPath
+	}
+
+	return filepath.Join(nodeEnvPath, "bin")
+}
+
+// newNodeEnv returns the environment for a child process using the Node.js installed in nodeEnvPath.
+// The environment of nodapt itself is left untouched, so several versions can be used concurrently.
+// With a shared global prefix, its bin directory comes right after the one of the version.
+//
+// Parameters:
+//   - nodeEnvPath: The directory where the Node.js version is installed.
+//   - extras: Additional environment variables to set, may be nil.
+func newNodeEnv(nodeEnvPath string, extras map[string]string) *util.Env {
+	env := util.NewEnv(os.Environ())
+
+	prefix := getGlobalPrefix(nodeEnvPath)
+	path := env.Get("PATH")
+
+	if prefix != nodeEnvPath {
+		path = util.AppendEnvPath(path, getBinaryDir(prefix))
+	}
+
+	env.Set("PATH", util.AppendEnvPath(path, getBinaryDir(nodeEnvPath)))
+	env.Set("NPM_CONFIG_PREFIX", prefix)
+
+	for k, v := range extras {
+		env.Set(k, v)
+	}
+
+	return env
+}
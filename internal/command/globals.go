@@ -0,0 +1,331 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/pkg/errors"
+)
+
+// bundledPackages are installed with node itself, so they are never migrated.
+var bundledPackages = map[string]bool{
+	"npm":      true,
+	"corepack": true,
+}
+
+// GlobalPackage is a top-level package installed globally with npm.
+type GlobalPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Link    string `json:"link,omitempty"` // The linked directory, only set for packages installed with npm link
+}
+
+// getGlobalModulesDir returns the node_modules directory of the global packages of the npm prefix.
+func getGlobalModulesDir(prefix string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(prefix, "node_modules")
+	}
+
+	return filepath.Join(prefix, "lib", "node_modules")
+}
+
+// listGlobalPackages returns the top-level global packages of the npm prefix,
+// without the packages bundled with node.
+func listGlobalPackages(prefix string) ([]GlobalPackage, error) {
+	modulesDir := getGlobalModulesDir(prefix)
+
+	names, err := readPackageNames(modulesDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make([]GlobalPackage, 0, len(names))
+
+	for _, name := range names {
+		if bundledPackages[name] {
+			continue
+		}
+
+		packageDir := filepath.Join(modulesDir, name)
+		pkg := GlobalPackage{Name: name}
+
+		if info, err := os.Lstat(packageDir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if link, err := filepath.EvalSymlinks(packageDir); err == nil {
+				pkg.Link = link
+			}
+		}
+
+		pkg.Version = readPackageVersion(packageDir)
+
+		packages = append(packages, pkg)
+	}
+
+	return packages, nil
+}
+
+// packageManifest is the part of the package.json of a package that nodapt reads.
+type packageManifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// readPackageManifest returns the manifest of the package.json in the package directory,
+// the fields are empty if it can't be read.
+func readPackageManifest(packageDir string) packageManifest {
+	var manifest packageManifest
+
+	content, err := os.ReadFile(filepath.Join(packageDir, "package.json"))
+
+	if err != nil {
+		return manifest
+	}
+
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return packageManifest{}
+	}
+
+	return manifest
+}
+
+// readPackageVersion returns the version of the package.json in the package directory,
+// or an empty string if it can't be read.
+func readPackageVersion(packageDir string) string {
+	return readPackageManifest(packageDir).Version
+}
+
+// readPackageNames returns the names of the packages in the node_modules directory, including scoped packages.
+func readPackageNames(modulesDir string) ([]string, error) {
+	entries, err := os.ReadDir(modulesDir)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	names := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if strings.HasPrefix(name, "@") {
+			scoped, err := os.ReadDir(filepath.Join(modulesDir, name))
+
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			for _, s := range scoped {
+				if !strings.HasPrefix(s.Name(), ".") {
+					names = append(names, name+"/"+s.Name())
+				}
+			}
+
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// installSpec returns the argument of npm install that reinstalls the package.
+func (p GlobalPackage) installSpec() string {
+	if p.Link != "" {
+		return p.Link
+	}
+
+	if p.Version != "" {
+		return p.Name + "@" + p.Version
+	}
+
+	return p.Name
+}
+
+// migrateGlobals reinstalls the global packages of the Node.js installed in fromPath
+// into the one installed in toPath with its npm. Every package is installed separately,
+// so a failing package doesn't stop the others, the failures are reported at the end.
+func migrateGlobals(fromPath string, toPath string) error {
+	fromPrefix := getGlobalPrefix(fromPath)
+
+	if fromPrefix == getGlobalPrefix(toPath) {
+		fmt.Fprintf(os.Stderr, "The versions share the global packages in %s, nothing to migrate\n", fromPrefix)
+		return nil
+	}
+
+	packages, err := listGlobalPackages(fromPrefix)
+
+	if err != nil {
+		return err
+	}
+
+	if len(packages) == 0 {
+		fmt.Fprintf(os.Stderr, "No global packages to migrate from %s\n", fromPrefix)
+		return nil
+	}
+
+	env := newNodeEnv(toPath, nil)
+
+	npmPath, err := env.LookPath("npm")
+
+	if err != nil {
+		return errors.WithMessagef(err, "npm not found in %s", toPath)
+	}
+
+	failures := make([]string, 0)
+
+	for _, pkg := range packages {
+		fmt.Fprintf(os.Stderr, "Installing %s\n", pkg.installSpec())
+
+		process := exec.Command(npmPath, "install", "--global", pkg.installSpec())
+
+		process.Env = env.Environ()
+		process.Stdout = os.Stderr
+		process.Stderr = os.Stderr
+
+		if err := process.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to install %s: %v\n", pkg.installSpec(), err)
+			failures = append(failures, pkg.Name)
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("failed to migrate %d of %d global packages: %s", len(failures), len(packages), strings.Join(failures, ", "))
+	}
+
+	fmt.Fprintf(os.Stderr, "Migrated %d global packages\n", len(packages))
+
+	return nil
+}
+
+type GlobalsListOptions struct {
+	JSON bool // Print the result as JSON
+}
+
+type GlobalsMigrateOptions struct {
+	From string // The constraint of the installed version to migrate from
+	To   string // The constraint of the version to migrate to, it is installed if needed
+}
+
+// findInstalledVersion returns the newest installed version which satisfies the constraint or alias.
+func findInstalledVersion(constraint string) (*node.CachedNode, error) {
+	expanded, err := expandAlias(constraint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cachedNodes, err := node.GetCachedVersions(nodapt_dir)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return findCachedVersion(cachedNodes, expanded)
+}
+
+// ListGlobals prints the global npm packages of the installed version matching the constraint.
+func ListGlobals(constraint string, options *GlobalsListOptions) error {
+	cache, err := findInstalledVersion(constraint)
+
+	if err != nil {
+		return err
+	}
+
+	if cache == nil {
+		return errors.Errorf("no installed node version matches %s", constraint)
+	}
+
+	prefix := getGlobalPrefix(cache.FilePath)
+
+	packages, err := listGlobalPackages(prefix)
+
+	if err != nil {
+		return err
+	}
+
+	if options.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return errors.WithStack(encoder.Encode(packages))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "NAME\tVERSION\tLINK\n")
+
+	for _, pkg := range packages {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", pkg.Name, orDash(pkg.Version), orDash(pkg.Link))
+	}
+
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nGlobal packages of node %s in %s\n", cache.Version, getGlobalModulesDir(prefix))
+
+	return nil
+}
+
+// MigrateGlobals reinstalls the global npm packages of an installed version into another version.
+func MigrateGlobals(options *GlobalsMigrateOptions) error {
+	from, err := findInstalledVersion(options.From)
+
+	if err != nil {
+		return err
+	}
+
+	if from == nil {
+		return errors.Errorf("no installed node version matches %s", options.From)
+	}
+
+	toPath := ""
+
+	if to, err := findInstalledVersion(options.To); err != nil {
+		return err
+	} else if to != nil {
+		toPath = to.FilePath
+	} else {
+		expanded, err := expandAlias(options.To)
+
+		if err != nil {
+			return err
+		}
+
+		version, err := node.GetMatchVersion(expanded)
+
+		if err != nil {
+			return errors.WithMessage(err, "failed to get match version")
+		}
+
+		if version == nil {
+			return errors.Errorf("no match version found for %s", options.To)
+		}
+
+		if toPath, err = node.Download(*version, nodapt_dir); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if toPath == from.FilePath {
+		return errors.Errorf("%s and %s are the same node version %s", options.From, options.To, from.Version)
+	}
+
+	return migrateGlobals(from.FilePath, toPath)
+}
@@ -0,0 +1,42 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListGlobalPackages(t *testing.T) {
+	nodeEnvPath := t.TempDir()
+	modulesDir := getGlobalModulesDir(nodeEnvPath)
+
+	writePackage := func(name string, content string) {
+		dir := filepath.Join(modulesDir, name)
+		assert.Nil(t, os.MkdirAll(dir, 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(content), 0644))
+	}
+
+	packages, err := listGlobalPackages(nodeEnvPath)
+	assert.Nil(t, err)
+	assert.Empty(t, packages)
+
+	writePackage("npm", `{"version": "10.2.4"}`)
+	writePackage("corepack", `{"version": "0.23.0"}`)
+	writePackage("typescript", `{"version": "5.3.3"}`)
+	writePackage("@vue/cli", `{"version": "5.0.8"}`)
+	writePackage("broken", `not json`)
+	assert.Nil(t, os.MkdirAll(filepath.Join(modulesDir, ".bin"), 0755))
+
+	packages, err = listGlobalPackages(nodeEnvPath)
+	assert.Nil(t, err)
+	assert.Equal(t, []GlobalPackage{
+		{Name: "@vue/cli", Version: "5.0.8"},
+		{Name: "broken"},
+		{Name: "typescript", Version: "5.3.3"},
+	}, packages)
+
+	assert.Equal(t, "@vue/cli@5.0.8", packages[0].installSpec())
+	assert.Equal(t, "broken", packages[1].installSpec())
+}
@@ -0,0 +1,31 @@
+package command
+
+import (
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// Download the headers of the selected version for node-gyp before running the command
+var with_headers bool
+
+// SetWithHeaders makes run and use download the headers of the selected version.
+func SetWithHeaders(withHeaders bool) {
+	with_headers = withHeaders
+}
+
+// getNodeHeaders returns the headers of the version for node-gyp, downloading them with --with-headers
+// or NODAPT_WITH_HEADERS=1. It returns an empty string if they are not downloaded.
+func getNodeHeaders(version string) (string, error) {
+	if with_headers || util.GetEnvsWithFallback("", "NODAPT_WITH_HEADERS") == "1" {
+		headersDir, err := node.DownloadHeaders(version, nodapt_dir)
+
+		if err != nil {
+			return "", errors.WithMessagef(err, "failed to download the headers of node %s", version)
+		}
+
+		return headersDir, nil
+	}
+
+	return node.GetHeaders(version, nodapt_dir), nil
+}
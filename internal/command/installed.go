@@ -0,0 +1,49 @@
+package command
+
+import (
+	"os"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// InstalledVersion describes a node version installed by nodapt.
+type InstalledVersion struct {
+	Version          string     `json:"version"`
+	Platform         string     `json:"platform"`
+	Arch             string     `json:"arch"`
+	Path             string     `json:"path"`
+	Size             int64      `json:"size"`
+	InstalledAt      time.Time  `json:"installedAt"`
+	LastUsedAt       *time.Time `json:"lastUsedAt"`       // nil if it has never been used by run or use
+	SatisfiesProject *bool      `json:"satisfiesProject"` // nil if the current project has no constraint
+	Selected         bool       `json:"selected"`         // Whether run selects this version in the current directory
+	Default          bool       `json:"default"`          // Whether it is the newest installed version of the default alias
+}
+
+// getInstalledVersion collects the disk usage and usage information of an installed version.
+func getInstalledVersion(cache node.CachedNode) (*InstalledVersion, error) {
+	size, err := util.DirSize(cache.FilePath)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	stat, err := os.Stat(cache.FilePath)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &InstalledVersion{
+		Version:     cache.Version,
+		Platform:    cache.Platform,
+		Arch:        cache.Arch,
+		Path:        cache.FilePath,
+		Size:        size,
+		InstalledAt: stat.ModTime(),
+		LastUsedAt:  node.GetLastUsed(nodapt_dir, cache.FilePath),
+	}, nil
+}
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/pkg/errors"
+)
+
+// Lock resolves the constraint of the package.json of the current working directory to the newest matching version,
+// and records it with the checksums of every platform in the lockfile next to package.json.
+func Lock() error {
+	project, err := getPackageConstraint()
+
+	if err != nil {
+		return err
+	}
+
+	if project == nil {
+		return errors.New("no node constraint found, add engines.node to package.json or run 'nodapt pin'")
+	}
+
+	versions, err := node.GetAllVersions()
+
+	if err != nil {
+		return errors.WithMessage(err, "failed to get match version")
+	}
+
+	match, err := findRemoteVersion(versions, project.Constraint, project.Npm)
+
+	if err != nil {
+		return err
+	}
+
+	if match == nil && project.Npm != "" {
+		fmt.Fprintf(os.Stderr, "Warning: no node version satisfying %s bundles npm %s, the npm of engines is ignored\n", project.Constraint, project.Npm)
+
+		if match, err = findRemoteVersion(versions, project.Constraint, ""); err != nil {
+			return err
+		}
+	}
+
+	if match == nil {
+		return errors.Errorf("no match version found for %s", project.Constraint)
+	}
+
+	checksums, err := node.GetChecksums(match.Version)
+
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get the checksums of node %s", match.Version)
+	}
+
+	lock := node.NewLock(project.Constraint, match.Version, checksums)
+
+	if lock.Artifact() == nil {
+		return errors.Errorf("node %s is not published for this platform", match.Version)
+	}
+
+	lockFilePath := filepath.Join(filepath.Dir(project.File), node.LockFileName)
+
+	if err := node.WriteLock(lockFilePath, lock); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Locked node %s for %s in %s\n", lock.Version, project.Constraint, lockFilePath)
+
+	return nil
+}
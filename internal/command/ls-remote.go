@@ -1,22 +1,134 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/version_constraint"
 	"github.com/pkg/errors"
 )
 
-func ListRemote() error {
+type ListRemoteOptions struct {
+	LTS         bool       // Only list LTS releases
+	Constraint  *string    // Only list the releases which satisfy the constraint
+	MajorLatest bool       // Only list the newest release of every major
+	Since       *time.Time // Only list the releases published on or after the date
+	Limit       int        // List at most this many releases, 0 means no limit
+	JSON        bool       // Print the result as JSON
+}
+
+// filterRemoteVersions returns the versions matching the options, in the order of the index (newest first).
+func filterRemoteVersions(versions node.Versions, options *ListRemoteOptions) (node.Versions, error) {
+	result := make(node.Versions, 0)
+	seenMajors := map[uint64]bool{}
+
+	for _, v := range versions {
+		if options.LTS && v.LTSName() == "" {
+			continue
+		}
+
+		if options.Constraint != nil {
+			if ok, err := version_constraint.Match(*options.Constraint, v.Version); err != nil {
+				return nil, errors.WithStack(err)
+			} else if !ok {
+				continue
+			}
+		}
+
+		if options.Since != nil {
+			date, err := time.Parse(time.DateOnly, v.Date)
+
+			if err != nil || date.Before(*options.Since) {
+				continue
+			}
+		}
+
+		if options.MajorLatest {
+			ver, err := semver.NewVersion(v.Version)
+
+			if err != nil {
+				continue
+			}
+
+			if seenMajors[ver.Major()] {
+				continue
+			}
+
+			seenMajors[ver.Major()] = true
+		}
+
+		result = append(result, v)
+
+		if options.Limit > 0 && len(result) >= options.Limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+func ListRemote(options *ListRemoteOptions) error {
+	if options.Constraint != nil {
+		constraint, err := expandAlias(*options.Constraint)
+
+		if err != nil {
+			return err
+		}
+
+		options.Constraint = &constraint
+	}
+
 	versions, err := node.GetAllVersions()
 
 	if err != nil {
 		return errors.WithMessage(err, "failed to get node versions")
 	}
 
-	for _, version := range versions {
-		fmt.Println(version.Version)
+	versions, err = filterRemoteVersions(versions, options)
+
+	if err != nil {
+		return err
+	}
+
+	if options.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return errors.WithStack(encoder.Encode(versions))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "VERSION\tDATE\tLTS\tNPM\tV8\tOPENSSL\tSECURITY")
+
+	for _, v := range versions {
+		lts := v.LTSName()
+
+		if lts == "" {
+			lts = "-"
+		}
+
+		security := "-"
+
+		if v.Security {
+			security = "yes"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", v.Version, v.Date, lts, orDash(v.Npm), orDash(v.V8), orDash(v.OpenSSL), security)
+	}
+
+	return errors.WithStack(w.Flush())
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
 	}
 
-	return nil
+	return s
 }
@@ -0,0 +1,79 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterRemoteVersions(t *testing.T) {
+	versions := node.Versions{
+		{Version: "v22.1.0", Date: "2024-05-02", LTS: false},
+		{Version: "v22.0.0", Date: "2024-04-24", LTS: false},
+		{Version: "v20.12.2", Date: "2024-04-10", LTS: "Iron", Security: true},
+		{Version: "v20.12.1", Date: "2024-04-03", LTS: "Iron"},
+		{Version: "v18.20.2", Date: "2024-04-10", LTS: "Hydrogen", Security: true},
+		{Version: "v18.20.1", Date: "2024-04-03", LTS: "Hydrogen"},
+	}
+
+	versionsOf := func(list node.Versions) []string {
+		result := make([]string, 0, len(list))
+		for _, v := range list {
+			result = append(result, v.Version)
+		}
+		return result
+	}
+
+	constraint := "^20 || ^22"
+	since := time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		options  ListRemoteOptions
+		expected []string
+	}{
+		{
+			name:     "no filter",
+			options:  ListRemoteOptions{},
+			expected: []string{"v22.1.0", "v22.0.0", "v20.12.2", "v20.12.1", "v18.20.2", "v18.20.1"},
+		},
+		{
+			name:     "lts",
+			options:  ListRemoteOptions{LTS: true},
+			expected: []string{"v20.12.2", "v20.12.1", "v18.20.2", "v18.20.1"},
+		},
+		{
+			name:     "major latest",
+			options:  ListRemoteOptions{MajorLatest: true},
+			expected: []string{"v22.1.0", "v20.12.2", "v18.20.2"},
+		},
+		{
+			name:     "constraint and limit",
+			options:  ListRemoteOptions{Constraint: &constraint, Limit: 3},
+			expected: []string{"v22.1.0", "v22.0.0", "v20.12.2"},
+		},
+		{
+			name:     "since",
+			options:  ListRemoteOptions{Since: &since},
+			expected: []string{"v22.1.0", "v22.0.0", "v20.12.2", "v18.20.2"},
+		},
+		{
+			name:     "lts and major latest",
+			options:  ListRemoteOptions{LTS: true, MajorLatest: true},
+			expected: []string{"v20.12.2", "v18.20.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := filterRemoteVersions(versions, &tt.options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, versionsOf(result))
+		})
+	}
+
+	assert.Equal(t, "Iron", versions[2].LTSName())
+	assert.Equal(t, "", versions[0].LTSName())
+}
@@ -1,22 +1,179 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/axetroy/nodapt/internal/version_constraint"
 	"github.com/pkg/errors"
 )
 
-func List() error {
+type ListOptions struct {
+	JSON       bool    // Print the result as JSON
+	Constraint *string // Only list the versions which satisfy the constraint
+}
+
+func List(options *ListOptions) error {
+	list, project, resolution, err := listInstalled(options.Constraint)
+
+	if err != nil {
+		return err
+	}
+
+	if err := printInstalled(os.Stdout, list, options.JSON); err != nil {
+		return err
+	}
+
+	if options.JSON {
+		return nil
+	}
+
+	if project != nil {
+		fmt.Fprintf(os.Stderr, "\nConstraint %s from %s, * marks the installed version selected by run\n", project.Constraint, project.File)
+	}
+
+	if resolution != nil && resolution.Source == SourceSystem && resolution.Version != "" {
+		fmt.Fprintf(os.Stderr, "run uses the system node %s in the current directory\n", resolution.Version)
+	}
+
+	return nil
+}
+
+// listInstalled returns the installed versions which satisfy the constraint if any, with the project constraint
+// of the current working directory and the node selected by run there, which is nil if it can't be resolved.
+func listInstalled(constraint *string) ([]*InstalledVersion, *ProjectConstraint, *Resolution, error) {
+	if constraint != nil {
+		expanded, err := expandAlias(*constraint)
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		constraint = &expanded
+	}
+
 	cached, err := node.GetCachedVersions(nodapt_dir)
 
 	if err != nil {
-		return errors.WithStack(err)
+		return nil, nil, nil, errors.WithStack(err)
 	}
 
+	project, err := getProjectConstraint()
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Listing works offline, so the selection of run is only shown when it can be resolved
+	_, resolution, err := resolveProject(false)
+
+	if err != nil {
+		util.Debug("Failed to resolve the node selected by run: %v\n", err)
+		resolution = nil
+	}
+
+	var defaultVersion *node.CachedNode
+
+	if defaultConstraint, err := getDefaultConstraint(); err != nil {
+		return nil, nil, nil, err
+	} else if defaultConstraint != nil {
+		if defaultVersion, err = findCachedVersion(cached, *defaultConstraint); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	list := make([]*InstalledVersion, 0, len(cached))
+
 	for _, c := range cached {
-		fmt.Println(c.Version)
+		if constraint != nil {
+			if ok, err := version_constraint.Match(*constraint, c.Version); err != nil {
+				return nil, nil, nil, errors.WithStack(err)
+			} else if !ok {
+				continue
+			}
+		}
+
+		installed, err := getInstalledVersion(c)
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if project != nil {
+			satisfies, err := version_constraint.Match(project.Constraint, c.Version)
+
+			if err != nil {
+				return nil, nil, nil, errors.WithStack(err)
+			}
+
+			installed.SatisfiesProject = &satisfies
+		}
+
+		installed.Selected = resolution != nil && resolution.Source == SourceInstalled && resolution.Path == c.FilePath
+		installed.Default = defaultVersion != nil && defaultVersion.FilePath == c.FilePath
+
+		list = append(list, installed)
 	}
 
-	return nil
+	return list, project, resolution, nil
+}
+
+// printInstalled writes the installed versions into out as a table, or as JSON.
+func printInstalled(out io.Writer, list []*InstalledVersion, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		return errors.WithStack(encoder.Encode(list))
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "  VERSION\tPLATFORM\tSIZE\tINSTALLED\tLAST USED\tPROJECT\tDEFAULT")
+
+	for _, v := range list {
+		marker := " "
+
+		if v.Selected {
+			marker = "*"
+		}
+
+		platform := "-"
+
+		if v.Platform != "" {
+			platform = v.Platform + "-" + v.Arch
+		}
+
+		lastUsed := "never"
+
+		if v.LastUsedAt != nil {
+			lastUsed = v.LastUsedAt.Local().Format(time.DateTime)
+		}
+
+		satisfies := "-"
+
+		if v.SatisfiesProject != nil {
+			satisfies = "no"
+
+			if *v.SatisfiesProject {
+				satisfies = "yes"
+			}
+		}
+
+		isDefault := "-"
+
+		if v.Default {
+			isDefault = "yes"
+		}
+
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\t%s\t%s\n", marker, v.Version, platform, util.FormatSize(v.Size), v.InstalledAt.Local().Format(time.DateTime), lastUsed, satisfies, isDefault)
+	}
+
+	return errors.WithStack(w.Flush())
 }
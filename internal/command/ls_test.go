@@ -0,0 +1,96 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListInstalled(t *testing.T) {
+	origNodaptDir := nodapt_dir
+	defer func() { nodapt_dir = origNodaptDir }()
+	nodapt_dir = t.TempDir()
+
+	for _, name := range []string{"node-v18.20.0-linux-x64", "node-v20.11.0-linux-x64", "node-v20.11.1-linux-x64"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(nodapt_dir, "node", name, "bin"), 0755))
+	}
+
+	assert.NoError(t, SetAlias(DefaultAlias, "18"))
+
+	projectDir := t.TempDir()
+
+	// No system node is likely to be exactly 20.11.x, so run selects an installed version
+	assert.NoError(t, os.WriteFile(filepath.Join(projectDir, "package.json"), []byte(`{"engines": {"node": "20.11.x"}}`), 0644))
+
+	t.Chdir(projectDir)
+
+	constraint := func(c string) *string { return &c }
+
+	tests := []struct {
+		name       string
+		constraint *string
+		expected   []string
+	}{
+		{name: "All versions", expected: []string{"v18.20.0", "v20.11.0", "v20.11.1"}},
+		{name: "Filtered by constraint", constraint: constraint("^20"), expected: []string{"v20.11.0", "v20.11.1"}},
+		{name: "Filtered by alias", constraint: constraint(DefaultAlias), expected: []string{"v18.20.0"}},
+		{name: "No version matches", constraint: constraint("^22"), expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, project, _, err := listInstalled(tt.constraint)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "20.11.x", project.Constraint)
+
+			versions := []string{}
+
+			for _, v := range list {
+				versions = append(versions, v.Version)
+			}
+
+			assert.Equal(t, tt.expected, versions)
+		})
+	}
+
+	list, _, resolution, err := listInstalled(nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, SourceInstalled, resolution.Source)
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, printInstalled(&buf, list, true))
+
+	var decoded []struct {
+		Version          string `json:"version"`
+		Platform         string `json:"platform"`
+		Arch             string `json:"arch"`
+		SatisfiesProject *bool  `json:"satisfiesProject"`
+		Selected         bool   `json:"selected"`
+		Default          bool   `json:"default"`
+	}
+
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+
+	if assert.Len(t, decoded, 3) {
+		assert.Equal(t, "v18.20.0", decoded[0].Version)
+		assert.Equal(t, "linux", decoded[0].Platform)
+		assert.Equal(t, "x64", decoded[0].Arch)
+		assert.False(t, *decoded[0].SatisfiesProject)
+		assert.False(t, decoded[0].Selected)
+		assert.True(t, decoded[0].Default, "the newest installed version of the default alias")
+
+		assert.True(t, *decoded[1].SatisfiesProject)
+		assert.False(t, decoded[1].Selected)
+
+		assert.True(t, *decoded[2].SatisfiesProject)
+		assert.True(t, decoded[2].Selected, "the newest installed version satisfying the project is selected by run")
+		assert.False(t, decoded[2].Default)
+	}
+}
@@ -0,0 +1,410 @@
+package command
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/axetroy/nodapt/internal/version_constraint"
+	"github.com/pkg/errors"
+)
+
+type MatrixOptions struct {
+	Constraints []string // Run the command with the newest version matching every constraint, e.g. 18
+	FromEngines bool     // Also run it with the newest version of every major that the project constraint admits
+	Parallel    int      // How many versions run the command at the same time, their output is buffered then
+	JUnit       string   // The file to write the results into as JUnit XML, if any
+	Cmd         []string // The command to run
+}
+
+// matrixEntry is a version of node that matrix runs the command with.
+type matrixEntry struct {
+	Constraint string // The constraint the version is selected for
+	Version    string
+	Output     lockedBuffer // The output of the command, stdout and stderr interleaved
+	Err        error        // The error of preparing the command, which did not run then
+	Result     batchResult
+}
+
+// lockedBuffer is a buffer safe for the concurrent writes of stdout and stderr.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// Matrix runs the command once with every version selected by the constraints, installing them first.
+// The output of every version is prefixed with it, or printed as a block when it exits if several run in parallel.
+// A table of the results is printed at the end, and written as JUnit XML if requested.
+// It fails if the command fails with any version.
+func Matrix(options *MatrixOptions) error {
+	if len(options.Cmd) == 0 {
+		return errors.New("no command provided")
+	}
+
+	entries, err := getMatrixEntries("", options.Constraints, options.FromEngines)
+
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return errors.New("no version to run, pass constraints or --from-engines")
+	}
+
+	binDirs, err := getPackageManagerBinDirs("")
+
+	if err != nil {
+		return err
+	}
+
+	width := 0
+
+	for _, entry := range entries {
+		width = max(width, len(entry.Version))
+	}
+
+	buffered := options.Parallel > 1
+
+	var mu sync.Mutex
+
+	processes := make([]*exec.Cmd, len(entries))
+	writers := make([]*util.PrefixWriter, 0, len(entries)*2)
+
+	// Install every version before running anything
+	for i, entry := range entries {
+		process, err := newProcess(&RunOptions{Version: entry.Version, Cmd: options.Cmd, BinDirs: binDirs})
+
+		if err != nil {
+			entry.Err = err
+			fmt.Fprintf(os.Stderr, "%-*s | %v\n", width, entry.Version, err)
+			continue
+		}
+
+		if buffered {
+			process.Stdout = &entry.Output
+			process.Stderr = &entry.Output
+		} else {
+			prefix := fmt.Sprintf("%-*s | ", width, entry.Version)
+			stdout := util.NewPrefixWriter(os.Stdout, prefix, &mu)
+			stderr := util.NewPrefixWriter(os.Stderr, prefix, &mu)
+
+			process.Stdout = io.MultiWriter(stdout, &entry.Output)
+			process.Stderr = io.MultiWriter(stderr, &entry.Output)
+
+			writers = append(writers, stdout, stderr)
+		}
+
+		processes[i] = process
+	}
+
+	var done func(i int, result batchResult)
+
+	if buffered {
+		done = func(i int, result batchResult) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			output := entries[i].Output.String()
+
+			if output != "" && !strings.HasSuffix(output, "\n") {
+				output += "\n"
+			}
+
+			fmt.Fprintf(os.Stdout, "==> node %s (exit code %d, %s)\n%s", entries[i].Version, result.ExitCode, result.Duration.Round(time.Millisecond), output)
+		}
+	}
+
+	results := runBatch(processes, options.Parallel, done)
+
+	for _, w := range writers {
+		_ = w.Flush()
+	}
+
+	failed := 0
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "\nNODE\tCONSTRAINT\tRESULT\tEXIT CODE\tDURATION\n")
+
+	for i, entry := range entries {
+		entry.Result = results[i]
+
+		result, exitCode, duration := "ok", fmt.Sprint(entry.Result.ExitCode), entry.Result.Duration.Round(time.Millisecond).String()
+
+		if entry.Err != nil {
+			result, exitCode, duration = "error", "-", "-"
+		} else if entry.Result.Err != nil {
+			result = "failed"
+		}
+
+		if result != "ok" {
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Version, entry.Constraint, result, exitCode, duration)
+	}
+
+	if err := w.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if options.JUnit != "" {
+		if err := writeMatrixJUnit(options.JUnit, options.Cmd, entries); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("%d of %d versions failed", failed, len(entries))
+	}
+
+	return nil
+}
+
+// getMatrixEntries selects the newest version for every constraint, followed by the newest version of every major
+// admitted by the engines.node of the project in dir when fromEngines is true. A version selected twice only runs once.
+func getMatrixEntries(dir string, constraints []string, fromEngines bool) ([]*matrixEntry, error) {
+	enginesConstraint := ""
+
+	if fromEngines {
+		constraint, err := getEnginesNodeConstraint(dir)
+
+		if err != nil {
+			return nil, err
+		}
+
+		enginesConstraint = constraint
+	}
+
+	versions, err := getAllVersionsOrCached()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*matrixEntry
+
+	seen := map[string]bool{}
+
+	add := func(constraint string, version string) {
+		if !seen[version] {
+			seen[version] = true
+			entries = append(entries, &matrixEntry{Constraint: constraint, Version: version})
+		}
+	}
+
+	for _, c := range constraints {
+		expanded, err := expandAlias(c)
+
+		if err != nil {
+			return nil, err
+		}
+
+		match, err := findRemoteVersion(versions, expanded, "")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if match == nil {
+			return nil, errors.Errorf("no match version found for %s", c)
+		}
+
+		add(c, match.Version)
+	}
+
+	if fromEngines {
+		majors, err := getNewestOfMajors(versions, enginesConstraint)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(majors) == 0 {
+			return nil, errors.Errorf("no match version found for engines.node %s", enginesConstraint)
+		}
+
+		for _, version := range majors {
+			add(enginesConstraint, version)
+		}
+	}
+
+	return entries, nil
+}
+
+// getEnginesNodeConstraint returns the engines.node of the project in dir, which is required by --from-engines.
+// Unlike the constraint of run, devEngines.runtime and volta.node are not used.
+func getEnginesNodeConstraint(dir string) (string, error) {
+	engines, _, err := getPackageEngines(dir)
+
+	if err != nil {
+		return "", err
+	}
+
+	constraint := engines.Get("node")
+
+	if constraint == nil || *constraint == "" {
+		return "", errors.New("--from-engines requires engines.node in package.json")
+	}
+
+	return *constraint, nil
+}
+
+// getNewestOfMajors returns the newest version of every major which satisfies the constraint, from the oldest major.
+func getNewestOfMajors(versions node.Versions, constraint string) ([]string, error) {
+	newest := map[uint64]*semver.Version{}
+
+	for _, v := range versions {
+		if ok, err := version_constraint.Match(constraint, v.Version); err != nil {
+			return nil, errors.WithStack(err)
+		} else if !ok {
+			continue
+		}
+
+		sv, err := semver.NewVersion(v.Version)
+
+		if err != nil {
+			continue
+		}
+
+		if current, ok := newest[sv.Major()]; !ok || sv.GreaterThan(current) {
+			newest[sv.Major()] = sv
+		}
+	}
+
+	majors := make([]uint64, 0, len(newest))
+
+	for major := range newest {
+		majors = append(majors, major)
+	}
+
+	sort.Slice(majors, func(i, j int) bool { return majors[i] < majors[j] })
+
+	result := make([]string, 0, len(majors))
+
+	for _, major := range majors {
+		result = append(result, newest[major].Original())
+	}
+
+	return result, nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+}
+
+// writeMatrixJUnit writes the results of matrix into the file as JUnit XML, with a test case per version.
+func writeMatrixJUnit(file string, cmd []string, entries []*matrixEntry) error {
+	suite := junitTestSuite{
+		Name:      strings.Join(cmd, " "),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	var total time.Duration
+
+	for _, entry := range entries {
+		testCase := junitTestCase{
+			Name:      fmt.Sprintf("node %s", entry.Version),
+			ClassName: "nodapt.matrix",
+			Time:      formatSeconds(entry.Result.Duration),
+			SystemOut: entry.Output.String(),
+		}
+
+		if entry.Err != nil {
+			testCase.Error = &junitMessage{Message: entry.Err.Error()}
+			suite.Errors++
+		} else if entry.Result.Err != nil {
+			testCase.Failure = &junitMessage{Message: fmt.Sprintf("exit code %d", entry.Result.ExitCode), Type: "ExitCode"}
+			suite.Failures++
+		}
+
+		total += entry.Result.Duration
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	suite.Time = formatSeconds(total)
+
+	suites := junitTestSuites{
+		Name:     "nodapt matrix",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	content, err := xml.MarshalIndent(suites, "", "  ")
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	content = append([]byte(xml.Header), append(content, '\n')...)
+
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		return errors.WithMessagef(err, "failed to write %s", file)
+	}
+
+	return nil
+}
+
+// formatSeconds formats the duration in seconds as JUnit expects, e.g. 1.234.
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
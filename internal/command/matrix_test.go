@@ -0,0 +1,124 @@
+package command
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNewestOfMajors(t *testing.T) {
+	versions := node.Versions{
+		{Version: "v22.1.0"},
+		{Version: "v21.7.3"},
+		{Version: "v20.12.0"},
+		{Version: "v20.11.1"},
+		{Version: "v18.20.0"},
+		{Version: "v18.19.1"},
+		{Version: "v16.20.2"},
+	}
+
+	tests := []struct {
+		constraint  string
+		expected    []string
+		expectError bool
+	}{
+		{constraint: ">=18", expected: []string{"v18.20.0", "v20.12.0", "v21.7.3", "v22.1.0"}},
+		{constraint: "^18 || ^20", expected: []string{"v18.20.0", "v20.12.0"}},
+		{constraint: ">=18 <18.20", expected: []string{"v18.19.1"}},
+		{constraint: "^24", expected: []string{}},
+		{constraint: "invalid", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			result, err := getNewestOfMajors(versions, tt.constraint)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetEnginesNodeConstraint(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		expected    string
+		expectError bool
+	}{
+		{name: "With engines.node", packageJSON: `{"engines": {"node": ">=18"}}`, expected: ">=18"},
+		{name: "Ignore volta.node", packageJSON: `{"engines": {"node": ">=18"}, "volta": {"node": "20.12.0"}}`, expected: ">=18"},
+		{name: "Only volta.node", packageJSON: `{"volta": {"node": "20.12.0"}}`, expectError: true},
+		{name: "Without engines.node", packageJSON: `{"engines": {"npm": "^10"}}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(tt.packageJSON), 0644))
+
+			got, err := getEnginesNodeConstraint(dir)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestWriteMatrixJUnit(t *testing.T) {
+	passed := &matrixEntry{Constraint: "18", Version: "v18.20.0", Result: batchResult{ExitCode: 0, Duration: 1500 * time.Millisecond}}
+	_, _ = passed.Output.Write([]byte("all tests passed\n"))
+
+	failed := &matrixEntry{Constraint: "20", Version: "v20.12.0", Result: batchResult{ExitCode: 1, Duration: 250 * time.Millisecond, Err: errors.New("exit status 1")}}
+	_, _ = failed.Output.Write([]byte("1 test <failed>\n"))
+
+	broken := &matrixEntry{Constraint: "22", Version: "v22.1.0", Err: errors.New("download failed")}
+
+	file := filepath.Join(t.TempDir(), "junit.xml")
+
+	assert.NoError(t, writeMatrixJUnit(file, []string{"npm", "test"}, []*matrixEntry{passed, failed, broken}))
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(content, &suites))
+
+	assert.Equal(t, 3, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	assert.Equal(t, 1, suites.Errors)
+	assert.Equal(t, "1.750", suites.Time)
+
+	if assert.Len(t, suites.Suites, 1) && assert.Len(t, suites.Suites[0].Cases, 3) {
+		suite := suites.Suites[0]
+
+		assert.Equal(t, "npm test", suite.Name)
+
+		assert.Equal(t, "node v18.20.0", suite.Cases[0].Name)
+		assert.Equal(t, "1.500", suite.Cases[0].Time)
+		assert.Nil(t, suite.Cases[0].Failure)
+		assert.Equal(t, "all tests passed\n", suite.Cases[0].SystemOut)
+
+		assert.Equal(t, &junitMessage{Message: "exit code 1", Type: "ExitCode"}, suite.Cases[1].Failure)
+		assert.Equal(t, "1 test <failed>\n", suite.Cases[1].SystemOut)
+
+		assert.Equal(t, &junitMessage{Message: "download failed"}, suite.Cases[2].Error)
+	}
+}
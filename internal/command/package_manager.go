@@ -0,0 +1,115 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/package_manager"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// getPackageManagerBinDirs returns the directories with the executables of the packageManager
+// of the nearest package.json of dir, they are installed into the nodapt directory if needed.
+// Without packageManager, the npm, pnpm and yarn pinned by volta are used instead,
+// and without either, the ones of the workspace root.
+// It returns nil if there is no package manager to install, and a package manager that fails to install
+// is skipped with a warning, so the one bundled with node is used instead.
+func getPackageManagerBinDirs(dir string) ([]string, error) {
+	dir, err := getWorkingDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	packageJSONPath := util.LoopUpFile(dir, "package.json")
+
+	if packageJSONPath == nil {
+		return nil, nil
+	}
+
+	fields, err := getPackageManagerFields(*packageJSONPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// A package of a workspace uses the package manager of the workspace root
+	if len(fields) == 0 {
+		rootPackageJSONPath, err := getWorkspaceRootPackageJSON(*packageJSONPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if rootPackageJSONPath != "" {
+			packageJSONPath = &rootPackageJSONPath
+
+			if fields, err = getPackageManagerFields(rootPackageJSONPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var binDirs []string
+
+	for _, field := range fields {
+		pm, err := package_manager.Parse(field)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignore package manager %s of %s: %v\n", field, *packageJSONPath, err)
+			continue
+		}
+
+		util.Debug("Use package manager %s from %s\n", pm, *packageJSONPath)
+
+		binDir, err := package_manager.Install(pm, nodapt_dir)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to install package manager %s of %s, use the one of node instead: %v\n", pm, *packageJSONPath, err)
+			continue
+		}
+
+		binDirs = append(binDirs, binDir)
+	}
+
+	return binDirs, nil
+}
+
+// getPackageManagerFields returns the package managers of the package.json in the format of packageManager,
+// that is the packageManager field, or else the npm, pnpm and yarn pinned by volta.
+func getPackageManagerFields(packageJSONPath string) ([]string, error) {
+	field, err := node.GetPackageManagerFromPackageJSON(packageJSONPath)
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get packageManager from %s", packageJSONPath)
+	}
+
+	if field != nil {
+		return []string{*field}, nil
+	}
+
+	volta, err := node.GetVoltaFromPackageJSON(packageJSONPath)
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get volta from %s", packageJSONPath)
+	}
+
+	if volta == nil {
+		return nil, nil
+	}
+
+	var fields []string
+
+	for _, pin := range []struct {
+		name    string
+		version *string
+	}{{"npm", volta.Npm}, {"pnpm", volta.Pnpm}, {"yarn", volta.Yarn}} {
+		if pin.version != nil {
+			fields = append(fields, pin.name+"@"+*pin.version)
+		}
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/axetroy/nodapt/internal/package_manager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPackageManagerBinDirs(t *testing.T) {
+	var requests atomic.Int32
+
+	// The registry is unreachable, every download fails
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	origNodaptDir, origRegistry := nodapt_dir, package_manager.NPM_REGISTRY
+	defer func() { nodapt_dir, package_manager.NPM_REGISTRY = origNodaptDir, origRegistry }()
+
+	nodapt_dir = t.TempDir()
+	package_manager.NPM_REGISTRY = server.URL + "/"
+
+	tests := []struct {
+		name        string
+		packageJSON string
+		requests    int32
+	}{
+		{name: "without package manager", packageJSON: `{"engines": {"node": "^18"}}`, requests: 0},
+		{name: "with packageManager", packageJSON: `{"packageManager": "pnpm@9.1.0"}`, requests: 1},
+		{name: "with volta", packageJSON: `{"volta": {"node": "18.20.0", "yarn": "1.22.19"}}`, requests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+
+			dir := t.TempDir()
+
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(tt.packageJSON), 0644))
+
+			binDirs, err := getPackageManagerBinDirs(dir)
+
+			assert.NoError(t, err, "a failed install is only a warning")
+			assert.Empty(t, binDirs)
+			assert.Equal(t, tt.requests, requests.Load())
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	PinPackageJSON = "package.json"  // Write engines.node of the nearest package.json
+	PinNvmrc       = ".nvmrc"        // Write the .nvmrc file next to the nearest package.json
+	PinNodeVersion = ".node-version" // Write the .node-version file next to the nearest package.json
+)
+
+type PinOptions struct {
+	Constraint *string // The constraint to pin, defaults to the active or the latest LTS version
+	File       string  // One of PinPackageJSON, PinNvmrc and PinNodeVersion
+}
+
+// getPinVersion returns the version of the node in PATH, or the latest LTS version if there is none.
+func getPinVersion() (string, error) {
+	if v := node.GetCurrentVersion(); v != nil {
+		util.Debug("Pin the active node version %s\n", *v)
+		return strings.TrimPrefix(*v, "v"), nil
+	}
+
+	versions, err := node.GetAllVersions()
+
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to get node versions")
+	}
+
+	for _, v := range versions {
+		if v.LTSName() != "" {
+			util.Debug("Pin the latest LTS version %s\n", v.Version)
+			return strings.TrimPrefix(v.Version, "v"), nil
+		}
+	}
+
+	return "", errors.New("no LTS version found")
+}
+
+// Pin writes the constraint into the project of the current working directory.
+func Pin(options *PinOptions) error {
+	var constraint string
+
+	if options.Constraint != nil {
+		expanded, err := expandAlias(*options.Constraint)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := semver.NewConstraint(expanded); err != nil {
+			return errors.WithMessagef(err, "invalid constraint %s", *options.Constraint)
+		}
+
+		constraint = expanded
+	} else {
+		version, err := getPinVersion()
+
+		if err != nil {
+			return err
+		}
+
+		// nvm and the like don't understand ranges, so the version files get the exact version
+		if options.File == PinPackageJSON {
+			constraint = "^" + version
+		} else {
+			constraint = version
+		}
+	}
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	packageJSONPath := util.LoopUpFile(cwd, "package.json")
+
+	if options.File != PinPackageJSON {
+		dir := cwd
+
+		if packageJSONPath != nil {
+			dir = filepath.Dir(*packageJSONPath)
+		}
+
+		filePath := filepath.Join(dir, options.File)
+
+		if err := os.WriteFile(filePath, []byte(constraint+"\n"), 0644); err != nil {
+			return errors.WithStack(err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Pinned node %s in %s\n", constraint, filePath)
+
+		return nil
+	}
+
+	if packageJSONPath == nil {
+		return errors.Errorf("package.json not found in %s or its parent directories", cwd)
+	}
+
+	content, err := os.ReadFile(*packageJSONPath)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	content, err = node.SetEngineInPackageJSON(content, "node", constraint)
+
+	if err != nil {
+		return errors.WithMessagef(err, "failed to update %s", *packageJSONPath)
+	}
+
+	info, err := os.Stat(*packageJSONPath)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.WriteFile(*packageJSONPath, content, info.Mode().Perm()); err != nil {
+		return errors.WithStack(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Pinned node %s in %s\n", constraint, *packageJSONPath)
+
+	return nil
+}
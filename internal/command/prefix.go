@@ -0,0 +1,211 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	GlobalsVersion = "version" // Every version has its own global packages, the default
+	GlobalsMajor   = "major"   // The versions of the same major share the global packages
+	GlobalsShared  = "shared"  // All the versions share the global packages
+)
+
+// Warn about an invalid NODAPT_GLOBALS once, although the mode is read several times by a command
+var warnGlobalsModeOnce sync.Once
+
+// getGlobalsMode returns the mode of the global packages set by NODAPT_GLOBALS.
+func getGlobalsMode() string {
+	mode := util.GetEnvsWithFallback(GlobalsVersion, "NODAPT_GLOBALS")
+
+	switch mode {
+	case GlobalsVersion, GlobalsMajor, GlobalsShared:
+		return mode
+	default:
+		warnGlobalsModeOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: invalid NODAPT_GLOBALS %s, expected %s, %s or %s\n", mode, GlobalsVersion, GlobalsMajor, GlobalsShared)
+		})
+
+		return GlobalsVersion
+	}
+}
+
+// getInstalledNodeVersion returns the version of the Node.js installed in nodeEnvPath, e.g. "v20.11.1".
+// The version is read from the directory name, like node-v20.11.1-linux-x64.
+func getInstalledNodeVersion(nodeEnvPath string) string {
+	parts := strings.Split(filepath.Base(nodeEnvPath), "-")
+
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return parts[1]
+}
+
+// getGlobalPrefix returns the npm prefix of the global packages of the Node.js installed in nodeEnvPath.
+func getGlobalPrefix(nodeEnvPath string) string {
+	switch getGlobalsMode() {
+	case GlobalsShared:
+		return filepath.Join(nodapt_dir, "globals", "shared")
+	case GlobalsMajor:
+		if v, err := semver.NewVersion(getInstalledNodeVersion(nodeEnvPath)); err == nil {
+			return filepath.Join(nodapt_dir, "globals", fmt.Sprintf("v%d", v.Major()))
+		}
+	}
+
+	return nodeEnvPath
+}
+
+var nodeModuleVersionPattern = regexp.MustCompile(`"node_module_version"\s*:\s*(\d+)`)
+
+// nativeAddon is a package of the node_modules directory with a native addon built by node-gyp.
+type nativeAddon struct {
+	Name          string    `json:"name"`
+	ModuleVersion string    `json:"moduleVersion"` // The NODE_MODULE_VERSION, the ABI of node, the addon was built for
+	File          string    `json:"file"`          // The config.gypi the ABI is read from
+	ModTime       time.Time `json:"modTime"`       // The modification time of File, which changes when the addon is rebuilt
+}
+
+// nativeAddonsCache is the result of findNativeAddons for a node_modules directory.
+type nativeAddonsCache struct {
+	ModTime time.Time     `json:"modTime"` // The modification time of the node_modules directory, which changes when a package is installed
+	Addons  []nativeAddon `json:"addons"`
+}
+
+func getNativeAddonsCacheFilePath() string {
+	return filepath.Join(nodapt_dir, "native-addons.json")
+}
+
+// findNativeAddons returns the packages of the node_modules directory, including the nested ones,
+// with a native addon built by node-gyp.
+func findNativeAddons(modulesDir string) []nativeAddon {
+	names, err := readPackageNames(modulesDir)
+
+	if err != nil {
+		util.Debug("Warning: failed to read %s: %v\n", modulesDir, err)
+		return nil
+	}
+
+	addons := make([]nativeAddon, 0)
+
+	for _, name := range names {
+		packageDir := filepath.Join(modulesDir, name)
+
+		// node-gyp records the headers it built against in build/config.gypi
+		configFile := filepath.Join(packageDir, "build", "config.gypi")
+
+		if content, err := os.ReadFile(configFile); err == nil {
+			if match := nodeModuleVersionPattern.FindSubmatch(content); match != nil {
+				addon := nativeAddon{Name: name, ModuleVersion: string(match[1]), File: configFile}
+
+				if stat, err := os.Stat(configFile); err == nil {
+					addon.ModTime = stat.ModTime()
+				}
+
+				addons = append(addons, addon)
+			}
+		}
+
+		addons = append(addons, findNativeAddons(filepath.Join(packageDir, "node_modules"))...)
+	}
+
+	return addons
+}
+
+// getNativeAddons is like findNativeAddons, but the result is cached in the nodapt directory until a package is installed
+// into or removed from modulesDir, or an addon is rebuilt, so the packages are not searched on every run.
+func getNativeAddons(modulesDir string) []nativeAddon {
+	stat, err := os.Stat(modulesDir)
+
+	if err != nil {
+		return nil
+	}
+
+	caches := map[string]nativeAddonsCache{}
+
+	if content, err := os.ReadFile(getNativeAddonsCacheFilePath()); err == nil {
+		if err := json.Unmarshal(content, &caches); err != nil {
+			util.Debug("Warning: failed to parse %s: %v\n", getNativeAddonsCacheFilePath(), err)
+		}
+	}
+
+	if cache, ok := caches[modulesDir]; ok && cache.ModTime.Equal(stat.ModTime()) && isNativeAddonsUpToDate(cache.Addons) {
+		return cache.Addons
+	}
+
+	addons := findNativeAddons(modulesDir)
+
+	caches[modulesDir] = nativeAddonsCache{ModTime: stat.ModTime(), Addons: addons}
+
+	if err := writeNativeAddonsCache(caches); err != nil {
+		util.Debug("Warning: failed to save %s: %v\n", getNativeAddonsCacheFilePath(), err)
+	}
+
+	return addons
+}
+
+// isNativeAddonsUpToDate reports whether none of the addons has been rebuilt or removed since they were found.
+func isNativeAddonsUpToDate(addons []nativeAddon) bool {
+	for _, addon := range addons {
+		if stat, err := os.Stat(addon.File); err != nil || !stat.ModTime().Equal(addon.ModTime) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func writeNativeAddonsCache(caches map[string]nativeAddonsCache) error {
+	content, err := json.Marshal(caches)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := util.EnsureDir(nodapt_dir); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.WriteFile(getNativeAddonsCacheFilePath(), content, 0644))
+}
+
+// warnGlobalsABI warns about the shared global packages with native addons built for another ABI than the version.
+func warnGlobalsABI(version string, prefix string) {
+	versions, err := node.GetCachedAllVersions()
+
+	if err != nil {
+		util.Debug("Warning: failed to load the cached index: %v\n", err)
+		return
+	}
+
+	moduleVersion := ""
+
+	for _, v := range versions {
+		if v.Version == "v"+strings.TrimPrefix(version, "v") {
+			moduleVersion = v.Modules
+			break
+		}
+	}
+
+	// Unknown without the index
+	if moduleVersion == "" {
+		return
+	}
+
+	for _, addon := range getNativeAddons(getGlobalModulesDir(prefix)) {
+		if addon.ModuleVersion != moduleVersion {
+			fmt.Fprintf(os.Stderr, "Warning: the global package %s was built for NODE_MODULE_VERSION %s, but node %s uses %s, run 'npm rebuild --global' to rebuild it\n", addon.Name, addon.ModuleVersion, version, moduleVersion)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGlobalPrefix(t *testing.T) {
+	origNodaptDir := nodapt_dir
+	defer func() { nodapt_dir = origNodaptDir }()
+	nodapt_dir = t.TempDir()
+
+	nodeEnvPath := filepath.Join(nodapt_dir, "node", "node-v20.11.1-linux-x64")
+
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{"", nodeEnvPath},
+		{GlobalsVersion, nodeEnvPath},
+		{GlobalsMajor, filepath.Join(nodapt_dir, "globals", "v20")},
+		{GlobalsShared, filepath.Join(nodapt_dir, "globals", "shared")},
+		{"invalid", nodeEnvPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			t.Setenv("NODAPT_GLOBALS", tt.mode)
+
+			assert.Equal(t, tt.expected, getGlobalPrefix(nodeEnvPath))
+		})
+	}
+
+	t.Run("shared bin after version bin", func(t *testing.T) {
+		t.Setenv("NODAPT_GLOBALS", GlobalsShared)
+
+		env := newNodeEnv(nodeEnvPath, nil)
+		paths := strings.Split(env.Get("PATH"), string(os.PathListSeparator))
+
+		assert.Equal(t, getBinaryDir(nodeEnvPath), paths[0])
+		assert.Equal(t, getBinaryDir(filepath.Join(nodapt_dir, "globals", "shared")), paths[1])
+		assert.Equal(t, filepath.Join(nodapt_dir, "globals", "shared"), env.Get("NPM_CONFIG_PREFIX"))
+	})
+}
+
+func TestFindNativeAddons(t *testing.T) {
+	modulesDir := t.TempDir()
+
+	writeFile := func(name string, content string) {
+		file := filepath.Join(modulesDir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.Nil(t, os.WriteFile(file, []byte(content), 0644))
+	}
+
+	writeFile("typescript/package.json", `{}`)
+	writeFile("sharp/build/config.gypi", "# Do not edit. File was generated by node-gyp's \"configure\" step\n{\n  \"variables\": {\n    \"node_module_version\": 108,\n  }\n}\n")
+	writeFile("@scope/cli/node_modules/bcrypt/build/config.gypi", `{"variables": {"node_module_version": 115}}`)
+	writeFile("broken/build/config.gypi", `{}`)
+
+	addons := findNativeAddons(modulesDir)
+
+	for i := range addons {
+		assert.False(t, addons[i].ModTime.IsZero())
+		addons[i].ModTime = time.Time{}
+	}
+
+	assert.Equal(t, []nativeAddon{
+		{Name: "bcrypt", ModuleVersion: "115", File: filepath.Join(modulesDir, "@scope/cli/node_modules/bcrypt/build/config.gypi")},
+		{Name: "sharp", ModuleVersion: "108", File: filepath.Join(modulesDir, "sharp/build/config.gypi")},
+	}, addons)
+}
+
+func TestGetNativeAddons(t *testing.T) {
+	origNodaptDir := nodapt_dir
+	defer func() { nodapt_dir = origNodaptDir }()
+	nodapt_dir = t.TempDir()
+
+	modulesDir := t.TempDir()
+	past := time.Now().Add(-time.Hour)
+
+	writeAddon := func(name string, moduleVersion string, modTime time.Time) {
+		file := filepath.Join(modulesDir, name, "build", "config.gypi")
+		assert.Nil(t, os.MkdirAll(filepath.Dir(file), 0755))
+		assert.Nil(t, os.WriteFile(file, []byte(`{"variables": {"node_module_version": `+moduleVersion+`}}`), 0644))
+		assert.Nil(t, os.Chtimes(file, modTime, modTime))
+	}
+
+	moduleVersions := func() []string {
+		versions := []string{}
+
+		for _, addon := range getNativeAddons(modulesDir) {
+			versions = append(versions, addon.Name+"@"+addon.ModuleVersion)
+		}
+
+		sort.Strings(versions)
+
+		return versions
+	}
+
+	writeAddon("sharp", "108", past)
+	assert.Nil(t, os.Chtimes(modulesDir, past, past))
+
+	assert.Equal(t, []string{"sharp@108"}, moduleVersions())
+
+	// The cache is used while no package is installed, a nested addon is not found then
+	writeAddon("sharp/node_modules/bcrypt", "108", past)
+	assert.Nil(t, os.Chtimes(modulesDir, past, past))
+
+	assert.Equal(t, []string{"sharp@108"}, moduleVersions())
+
+	// Rebuilding an addon invalidates the cache
+	writeAddon("sharp", "115", time.Now())
+
+	assert.Equal(t, []string{"bcrypt@108", "sharp@115"}, moduleVersions())
+
+	// Installing a package invalidates the cache
+	writeAddon("canvas", "115", past)
+
+	assert.Equal(t, []string{"bcrypt@108", "canvas@115", "sharp@115"}, moduleVersions())
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"github.com/axetroy/nodapt/internal/util"
+)
+
+// execute runs the process and waits for it to exit, forwarding the termination signals
+// received by nodapt to it, so stopping nodapt from a supervisor never orphans the child.
+//
+// When NODAPT_EXEC=1 is set, nodapt is replaced by the process on platforms supporting it,
+// the process then receives signals directly and nodapt does not return.
+func execute(process *exec.Cmd) error {
+	if util.GetEnvsWithFallback("", "NODAPT_EXEC") == "1" {
+		if err := replaceProcess(process); err != nil {
+			util.Debug("Failed to replace the process, fallback to a child process: %v\n", err)
+		}
+	}
+
+	return startAndWait(process)
+}
+
+// startAndWait runs the process and waits for it to exit like execute, but nodapt is never replaced,
+// so several processes may run concurrently.
+func startAndWait(process *exec.Cmd) error {
+	// Register before starting the child, so no signal is missed or kills nodapt in between
+	sigCh := make(chan os.Signal, 1)
+
+	signal.Notify(sigCh, terminationSignals...)
+
+	if err := process.Start(); err != nil {
+		signal.Stop(sigCh)
+		return err
+	}
+
+	go forwardSignals(sigCh, process.Process)
+
+	err := process.Wait()
+
+	// No signal is delivered to the channel once Stop returns, so it is safe to close it
+	signal.Stop(sigCh)
+	close(sigCh)
+
+	return err
+}
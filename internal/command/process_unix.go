@@ -0,0 +1,80 @@
+//go:build !windows
+
+package command
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// isTerminalSignal reports whether the signal is one the terminal sends to its foreground process group
+// on a key press, and the process belongs to that group, so it has already received the signal as well.
+// The sender of a signal is unknown, so a SIGINT or SIGQUIT sent to nodapt alone while the process is in the foreground
+// is taken as coming from the terminal too. SIGHUP is always forwarded, the terminal only sends it to the session leader.
+func isTerminalSignal(sig os.Signal, process *os.Process) bool {
+	if sig != syscall.SIGINT && sig != syscall.SIGQUIT {
+		return false
+	}
+
+	pgid, err := unix.Getpgid(process.Pid)
+
+	if err != nil {
+		return false
+	}
+
+	for _, fd := range []int{int(os.Stdin.Fd()), int(os.Stdout.Fd()), int(os.Stderr.Fd())} {
+		if pgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP); err == nil {
+			return pgrp == pgid
+		}
+	}
+
+	return false
+}
+
+// terminationSignals are the signals forwarded to the child.
+var terminationSignals = []os.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP, syscall.SIGQUIT}
+
+// forwardSignals forwards the signals received on ch to the process until ch is closed.
+func forwardSignals(ch chan os.Signal, process *os.Process) {
+	for sig := range ch {
+		// Avoid delivering terminal generated signals twice
+		if isTerminalSignal(sig, process) {
+			continue
+		}
+
+		_ = process.Signal(sig)
+	}
+}
+
+// replaceProcess replaces nodapt with the process, it only returns if that fails.
+// The process runs in its Dir, the working directory of nodapt is restored if the replacement fails.
+func replaceProcess(process *exec.Cmd) error {
+	if process.Err != nil {
+		return process.Err
+	}
+
+	env := process.Env
+
+	if env == nil {
+		env = os.Environ()
+	}
+
+	if process.Dir != "" {
+		wd, err := os.Getwd()
+
+		if err != nil {
+			return err
+		}
+
+		if err := os.Chdir(process.Dir); err != nil {
+			return err
+		}
+
+		defer func() { _ = os.Chdir(wd) }()
+	}
+
+	return syscall.Exec(process.Path, process.Args, env)
+}
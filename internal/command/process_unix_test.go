@@ -0,0 +1,111 @@
+//go:build unix
+
+package command
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStartAndWaitForwardsSignals(t *testing.T) {
+	process := exec.Command("/bin/sh", "-c", "sleep 5")
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+
+	err := startAndWait(process)
+
+	exitErr, ok := err.(*exec.ExitError)
+
+	if !ok {
+		t.Fatalf("startAndWait() error = %v, expected the child to be terminated", err)
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); !ok || !status.Signaled() || status.Signal() != syscall.SIGTERM {
+		t.Errorf("expected the child to be terminated by SIGTERM, got %v", exitErr)
+	}
+}
+
+func TestStartAndWaitUnregistersSignals(t *testing.T) {
+	// Children exiting immediately must not leave a closed channel registered
+	for i := 0; i < 50; i++ {
+		if err := startAndWait(exec.Command("/bin/true")); err != nil {
+			t.Fatalf("startAndWait() error = %v", err)
+		}
+	}
+
+	ch := make(chan os.Signal, 1)
+
+	signal.Notify(ch, syscall.SIGTERM)
+	defer signal.Stop(ch)
+
+	_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("SIGTERM was not delivered")
+	}
+}
+
+func TestStartAndWaitForwardsInterrupt(t *testing.T) {
+	// The child is in its own process group, so a SIGINT sent to nodapt alone never reaches it without forwarding
+	process := exec.Command("/bin/sh", "-c", "exec sleep 5")
+	process.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGINT)
+	}()
+
+	err := startAndWait(process)
+
+	exitErr, ok := err.(*exec.ExitError)
+
+	if !ok {
+		t.Fatalf("startAndWait() error = %v, expected the child to be interrupted", err)
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); !ok || !status.Signaled() || status.Signal() != syscall.SIGINT {
+		t.Errorf("expected the child to be interrupted by SIGINT, got %v", exitErr)
+	}
+}
+
+func TestReplaceProcessDir(t *testing.T) {
+	// Run as the process which is replaced, as replaceProcess never returns on success
+	if dir := os.Getenv("NODAPT_TEST_REPLACE_DIR"); dir != "" {
+		process := exec.Command("/bin/sh", "-c", "pwd -P")
+		process.Dir = dir
+
+		_ = replaceProcess(process)
+
+		os.Exit(1)
+	}
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+
+	if err != nil {
+		t.Fatalf("failed to resolve the directory: %v", err)
+	}
+
+	process := exec.Command(os.Args[0], "-test.run=^TestReplaceProcessDir$")
+	process.Env = append(os.Environ(), "NODAPT_TEST_REPLACE_DIR="+dir)
+
+	output, err := process.Output()
+
+	if err != nil {
+		t.Fatalf("failed to replace the process: %v", err)
+	}
+
+	if result := strings.TrimSpace(string(output)); result != dir {
+		t.Errorf("expected the process to run in %s, got %s", dir, result)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package command
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// terminationSignals are the signals nodapt must survive until the child has exited.
+var terminationSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// forwardSignals consumes the signals received on ch until ch is closed.
+func forwardSignals(ch chan os.Signal, process *os.Process) {
+	// The child shares the console with nodapt and receives the same control events,
+	// so just keep nodapt alive until the child has exited.
+	for range ch {
+	}
+}
+
+// replaceProcess is not supported on Windows.
+func replaceProcess(process *exec.Cmd) error {
+	return errors.New("replacing the process is not supported on windows")
+}
@@ -0,0 +1,227 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/axetroy/nodapt/internal/version_constraint"
+	"github.com/pkg/errors"
+)
+
+// ProjectConstraint is the node constraint of a project and the file it comes from.
+type ProjectConstraint struct {
+	Constraint string             `json:"constraint"`
+	File       string             `json:"file"`
+	Field      string             `json:"field,omitempty"`  // The field of package.json, e.g. engines.node
+	Npm        string             `json:"npm,omitempty"`    // The engines.npm of package.json, versions bundling a matching npm are preferred
+	OnFail     string             `json:"onFail,omitempty"` // What to do when the constraint can't be satisfied, see node.OnFailError
+	Fallback   *ProjectConstraint `json:"-"`                // The constraint used instead when this one fails and OnFail allows it
+}
+
+// getProjectConstraint returns the node constraint of the project in the current working directory.
+// When there is no package.json or it does not specify one, the default alias is used,
+// it returns nil if there is no default alias either.
+func getProjectConstraint() (*ProjectConstraint, error) {
+	return getProjectConstraintIn("")
+}
+
+// getProjectConstraintIn is like getProjectConstraint for the project in dir, the current working directory when it is empty.
+func getProjectConstraintIn(dir string) (*ProjectConstraint, error) {
+	project, err := getPackageConstraintIn(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	constraint, err := getDefaultConstraint()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if constraint == nil {
+		return project, nil
+	}
+
+	defaultConstraint := &ProjectConstraint{Constraint: *constraint, File: getAliasFilePath()}
+
+	if project == nil {
+		return defaultConstraint, nil
+	}
+
+	// The default version is the last resort of a constraint which may fail
+	last := project
+
+	for last.Fallback != nil {
+		last = last.Fallback
+	}
+
+	if last.OnFail == node.OnFailWarn || last.OnFail == node.OnFailIgnore {
+		last.Fallback = defaultConstraint
+	}
+
+	return project, nil
+}
+
+// resolveProjectConstraint resolves the project constraint like resolveConstraint. When it can't be satisfied
+// and its onFail is warn or ignore, the fallback constraint is resolved instead, with a warning for warn.
+// It returns the constraint in use, which is nil when every constraint failed and the system node is used.
+func resolveProjectConstraint(project *ProjectConstraint) (*ProjectConstraint, *Resolution, error) {
+	for p := project; p != nil; p = p.Fallback {
+		resolution, err := resolveConstraint(p.Constraint, p.Npm)
+
+		if err == nil {
+			return p, resolution, nil
+		}
+
+		switch p.OnFail {
+		case node.OnFailWarn:
+			fmt.Fprintf(os.Stderr, "Warning: %s %s of %s can't be satisfied: %v\n", p.Field, p.Constraint, p.File, err)
+		case node.OnFailIgnore:
+			util.Debug("Ignore %s %s of %s: %v\n", p.Field, p.Constraint, p.File, err)
+		default:
+			return nil, nil, err
+		}
+	}
+
+	resolution := &Resolution{Source: SourceSystem}
+
+	if v := node.GetCurrentVersion(); v != nil {
+		resolution.Version = *v
+	}
+
+	return nil, resolution, nil
+}
+
+// getPackageConstraint returns the node constraint of the package.json of the current working directory,
+// or nil if there is no package.json or it does not specify one.
+// A package of a workspace without a constraint inherits the one of the workspace root,
+// with --intersect-workspace its own constraint is intersected with the one of the root.
+func getPackageConstraint() (*ProjectConstraint, error) {
+	return getPackageConstraintIn("")
+}
+
+// getPackageConstraintIn is like getPackageConstraint for the package in dir, the current working directory when it is empty.
+func getPackageConstraintIn(dir string) (*ProjectConstraint, error) {
+	dir, err := getWorkingDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	packageJSONPath := util.LoopUpFile(dir, "package.json")
+
+	if packageJSONPath == nil {
+		return nil, nil
+	}
+
+	project, err := getPackageJSONConstraint(*packageJSONPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rootPackageJSONPath, err := getWorkspaceRootPackageJSON(*packageJSONPath)
+
+	if err != nil || rootPackageJSONPath == "" {
+		return project, err
+	}
+
+	root, err := getPackageJSONConstraint(rootPackageJSONPath)
+
+	if err != nil || root == nil {
+		return project, err
+	}
+
+	if project == nil {
+		util.Debug("Inherit node constraint %s from the workspace root %s\n", root.Constraint, rootPackageJSONPath)
+		return root, nil
+	}
+
+	if isWorkspaceIntersect() {
+		return intersectWorkspaceConstraint(project, root)
+	}
+
+	return project, nil
+}
+
+// getPackageJSONConstraint returns the node constraints of the package.json chained in order of precedence,
+// or nil if it does not specify one.
+func getPackageJSONConstraint(packageJSONPath string) (*ProjectConstraint, error) {
+	constraints, err := node.GetNodeConstraintsFromPackageJSON(packageJSONPath)
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get node constraint from %s", packageJSONPath)
+	}
+
+	if len(constraints) == 0 {
+		return nil, nil
+	}
+
+	engines, err := node.GetEnginesFromPackageJSON(packageJSONPath)
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get engines from %s", packageJSONPath)
+	}
+
+	var project *ProjectConstraint
+
+	// Chain the constraints in order of precedence, only the ones which may fail are followed by the next
+	for i := len(constraints) - 1; i >= 0; i-- {
+		p := &ProjectConstraint{
+			Constraint: constraints[i].Constraint,
+			File:       packageJSONPath,
+			Field:      constraints[i].Field,
+			OnFail:     constraints[i].OnFail,
+		}
+
+		if npm := engines.Get("npm"); npm != nil {
+			p.Npm = *npm
+		}
+
+		if p.OnFail == node.OnFailWarn || p.OnFail == node.OnFailIgnore {
+			p.Fallback = project
+		}
+
+		project = p
+	}
+
+	return project, nil
+}
+
+// getWorkingDir returns dir, or the current working directory when it is empty.
+func getWorkingDir(dir string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+
+	cwd, err := os.Getwd()
+
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return cwd, nil
+}
+
+// findCachedVersion returns the newest installed version which satisfies the constraint, or nil if there is none.
+func findCachedVersion(cachedNodes []node.CachedNode, constraint string) (*node.CachedNode, error) {
+	sorted := make([]node.CachedNode, len(cachedNodes))
+	copy(sorted, cachedNodes)
+
+	// Sort versions in descending order
+	sort.Sort(sort.Reverse(node.ByVersion(sorted)))
+
+	for _, cache := range sorted {
+		if ok, err := version_constraint.Match(constraint, cache.Version); err != nil {
+			return nil, errors.WithStack(err)
+		} else if ok {
+			return &cache, nil
+		}
+	}
+
+	return nil, nil
+}
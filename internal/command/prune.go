@@ -0,0 +1,175 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+type PruneOptions struct {
+	KeepLatestPerMajor bool           // Keep the newest installed version of every major
+	UnusedFor          *time.Duration // Only remove versions not used for this long
+	MaxSize            *int64         // Remove least recently used versions until the total size fits
+	DryRun             bool           // Print what would be removed without removing anything
+}
+
+type pruneCandidate struct {
+	Cache    node.CachedNode
+	Size     int64
+	LastUsed time.Time // The last time the version was used, or its install time if it never was
+}
+
+// selectPruneVictims returns the installed versions to remove, least recently used first.
+//
+// Parameters:
+//   - candidates: All the installed versions.
+//   - protected: The file path of the version required by the current project, which is never removed, may be nil.
+//   - options: The prune criteria, a version is removed only when it matches all of them.
+//   - now: The current time.
+func selectPruneVictims(candidates []pruneCandidate, protected *string, options *PruneOptions, now time.Time) []pruneCandidate {
+	keep := map[string]bool{}
+
+	if protected != nil {
+		keep[*protected] = true
+	}
+
+	if options.KeepLatestPerMajor {
+		latest := map[uint64]*semver.Version{}
+		latestPath := map[uint64]string{}
+
+		for _, c := range candidates {
+			v, err := semver.NewVersion(c.Cache.Version)
+
+			if err != nil {
+				// Never remove what we don't understand
+				keep[c.Cache.FilePath] = true
+				continue
+			}
+
+			if l, ok := latest[v.Major()]; !ok || v.GreaterThan(l) {
+				latest[v.Major()] = v
+				latestPath[v.Major()] = c.Cache.FilePath
+			}
+		}
+
+		for _, filePath := range latestPath {
+			keep[filePath] = true
+		}
+	}
+
+	var totalSize int64
+	victims := make([]pruneCandidate, 0)
+
+	for _, c := range candidates {
+		totalSize += c.Size
+
+		if keep[c.Cache.FilePath] {
+			continue
+		}
+
+		if options.UnusedFor != nil && now.Sub(c.LastUsed) < *options.UnusedFor {
+			continue
+		}
+
+		victims = append(victims, c)
+	}
+
+	// Least recently used first
+	sort.SliceStable(victims, func(i, j int) bool {
+		return victims[i].LastUsed.Before(victims[j].LastUsed)
+	})
+
+	if options.MaxSize == nil {
+		return victims
+	}
+
+	result := make([]pruneCandidate, 0)
+
+	for _, c := range victims {
+		if totalSize <= *options.MaxSize {
+			break
+		}
+
+		totalSize -= c.Size
+		result = append(result, c)
+	}
+
+	return result
+}
+
+// Prune removes installed versions according to the options,
+// never removing the version required by the project in the current working directory.
+func Prune(options *PruneOptions) error {
+	if !options.KeepLatestPerMajor && options.UnusedFor == nil && options.MaxSize == nil {
+		return errors.New("at least one of --keep-latest-per-major, --unused-for and --max-size is required")
+	}
+
+	cachedNodes, err := node.GetCachedVersions(nodapt_dir)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var protected *string
+
+	if project, err := getProjectConstraint(); err != nil {
+		return err
+	} else if project != nil {
+		if cache, err := findCachedVersion(cachedNodes, project.Constraint); err != nil {
+			return err
+		} else if cache != nil {
+			util.Debug("Keep node version %s required by %s\n", cache.Version, project.File)
+			protected = &cache.FilePath
+		}
+	}
+
+	candidates := make([]pruneCandidate, 0, len(cachedNodes))
+
+	for _, cache := range cachedNodes {
+		installed, err := getInstalledVersion(cache)
+
+		if err != nil {
+			return err
+		}
+
+		lastUsed := installed.InstalledAt
+
+		if installed.LastUsedAt != nil {
+			lastUsed = *installed.LastUsedAt
+		}
+
+		candidates = append(candidates, pruneCandidate{Cache: cache, Size: installed.Size, LastUsed: lastUsed})
+	}
+
+	victims := selectPruneVictims(candidates, protected, options, time.Now())
+
+	var freed int64
+
+	for _, c := range victims {
+		if options.DryRun {
+			fmt.Fprintf(os.Stderr, "Node version %s would be removed (%s, last used %s)\n", c.Cache.Version, util.FormatSize(c.Size), c.LastUsed.Local().Format(time.DateTime))
+		} else {
+			if err := node.RemoveCached(nodapt_dir, c.Cache); err != nil {
+				return errors.WithStack(err)
+			}
+
+			fmt.Fprintf(os.Stderr, "Node version %s has been removed (%s, last used %s)\n", c.Cache.Version, util.FormatSize(c.Size), c.LastUsed.Local().Format(time.DateTime))
+		}
+
+		freed += c.Size
+	}
+
+	if options.DryRun {
+		fmt.Fprintf(os.Stderr, "%d node version(s) would be removed, %s would be freed\n", len(victims), util.FormatSize(freed))
+	} else {
+		fmt.Fprintf(os.Stderr, "%d node version(s) removed, %s freed\n", len(victims), util.FormatSize(freed))
+	}
+
+	return nil
+}
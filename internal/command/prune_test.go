@@ -0,0 +1,94 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectPruneVictims(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	candidate := func(version string, size int64, lastUsed time.Duration) pruneCandidate {
+		return pruneCandidate{
+			Cache:    node.CachedNode{Version: version, FilePath: "/nodapt/node/node-" + version},
+			Size:     size,
+			LastUsed: now.Add(-lastUsed),
+		}
+	}
+
+	candidates := []pruneCandidate{
+		candidate("v16.20.0", 100, 90*day),
+		candidate("v18.19.0", 100, 60*day),
+		candidate("v18.20.0", 100, 40*day),
+		candidate("v20.10.0", 100, 10*day),
+		candidate("v20.11.1", 100, 1*day),
+	}
+
+	versions := func(victims []pruneCandidate) []string {
+		result := make([]string, 0, len(victims))
+		for _, v := range victims {
+			result = append(result, v.Cache.Version)
+		}
+		return result
+	}
+
+	duration := func(d time.Duration) *time.Duration { return &d }
+	size := func(s int64) *int64 { return &s }
+	protected := "/nodapt/node/node-v18.19.0"
+
+	tests := []struct {
+		name      string
+		protected *string
+		options   PruneOptions
+		expected  []string
+	}{
+		{
+			name:     "keep latest per major",
+			options:  PruneOptions{KeepLatestPerMajor: true},
+			expected: []string{"v18.19.0", "v20.10.0"},
+		},
+		{
+			name:      "keep latest per major and the project version",
+			protected: &protected,
+			options:   PruneOptions{KeepLatestPerMajor: true},
+			expected:  []string{"v20.10.0"},
+		},
+		{
+			name:     "unused for 30 days",
+			options:  PruneOptions{UnusedFor: duration(30 * day)},
+			expected: []string{"v16.20.0", "v18.19.0", "v18.20.0"},
+		},
+		{
+			name:     "unused for 30 days and keep latest per major",
+			options:  PruneOptions{UnusedFor: duration(30 * day), KeepLatestPerMajor: true},
+			expected: []string{"v18.19.0"},
+		},
+		{
+			name:     "max size removes least recently used first",
+			options:  PruneOptions{MaxSize: size(250)},
+			expected: []string{"v16.20.0", "v18.19.0", "v18.20.0"},
+		},
+		{
+			name:      "max size skips the project version",
+			protected: &protected,
+			options:   PruneOptions{MaxSize: size(300)},
+			expected:  []string{"v16.20.0", "v18.20.0"},
+		},
+		{
+			name:     "max size already satisfied",
+			options:  PruneOptions{MaxSize: size(1000)},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := selectPruneVictims(candidates, tt.protected, &tt.options, now)
+			assert.Equal(t, tt.expected, versions(result))
+		})
+	}
+}
@@ -10,6 +10,12 @@ import (
 )
 
 func Remove(constraint string) error {
+	constraint, err := expandAlias(constraint)
+
+	if err != nil {
+		return err
+	}
+
 	cachedNodes, err := node.GetCachedVersions(nodapt_dir)
 
 	if err != nil {
@@ -30,7 +36,7 @@ func Remove(constraint string) error {
 		}
 
 		if constraintVer.Check(v) {
-			err := os.RemoveAll(cache.FilePath)
+			err := node.RemoveCached(nodapt_dir, cache)
 
 			if err != nil {
 				return errors.WithStack(err)
@@ -0,0 +1,229 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/axetroy/nodapt/internal/version_constraint"
+	"github.com/pkg/errors"
+)
+
+const (
+	SourceSystem    = "system"    // The node found in PATH satisfies the constraint
+	SourceInstalled = "installed" // A version installed by nodapt satisfies the constraint
+	SourceRemote    = "remote"    // The newest matching version of the mirror, which needs to be installed
+)
+
+// Resolution is the node that run selects for a constraint.
+type Resolution struct {
+	Source  string `json:"source"`
+	Version string `json:"version"`
+	Path    string `json:"path"`             // The installation directory, only set for installed versions
+	SHA256  string `json:"sha256,omitempty"` // The checksum of the archive to verify, only set for locked versions
+}
+
+// getLockArtifact returns the archive to verify, or nil to download it unverified.
+func getLockArtifact(checksum string) *node.LockArtifact {
+	if checksum == "" {
+		return nil
+	}
+
+	return &node.LockArtifact{SHA256: checksum}
+}
+
+// resolveConstraint selects the node for the constraint the same way run does:
+// the system node if it satisfies the constraint, then the newest installed version,
+// and finally the newest matching version of the mirror. Nothing is installed.
+//
+// When npmConstraint is not empty, versions whose bundled npm satisfies it are preferred,
+// if there is none, the constraint of npm is ignored with a warning.
+func resolveConstraint(constraint string, npmConstraint string) (*Resolution, error) {
+	constraint, err := expandAlias(constraint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	installedVersion := node.GetCurrentVersion()
+
+	// If the node version is installed and the version satisfies the constraint, then use it directly
+	if installedVersion != nil {
+		util.Debug("Current node version: %s\n", *installedVersion)
+		if ok, err := version_constraint.Match(constraint, *installedVersion); err != nil {
+			return nil, errors.WithStack(err)
+		} else if ok && (npmConstraint == "" || matchNpm(npmConstraint, getIndexNpmVersion(*installedVersion))) {
+			util.Debug("Current node version %s is match the constraint.\n", *installedVersion)
+			return &Resolution{Source: SourceSystem, Version: *installedVersion}, nil
+		}
+	}
+
+	// Found cached node version
+	if cachedNodes, err := node.GetCachedVersions(nodapt_dir); err != nil {
+		return nil, errors.WithStack(err)
+	} else if cache, err := findCachedVersionWithNpm(cachedNodes, constraint, npmConstraint); err != nil {
+		return nil, err
+	} else if cache != nil {
+		util.Debug("Found cached node version %s is match the constraint.\n", cache.Version)
+		return &Resolution{Source: SourceInstalled, Version: cache.Version, Path: cache.FilePath}, nil
+	}
+
+	versions, err := node.GetAllVersions()
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get match version")
+	}
+
+	matchVersion, err := findRemoteVersion(versions, constraint, npmConstraint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if matchVersion == nil {
+		if npmConstraint != "" {
+			fmt.Fprintf(os.Stderr, "Warning: no node version satisfying %s bundles npm %s, the npm of engines is ignored\n", constraint, npmConstraint)
+			return resolveConstraint(constraint, "")
+		}
+
+		return nil, errors.Errorf("no match version found for %s", constraint)
+	}
+
+	return &Resolution{Source: SourceRemote, Version: matchVersion.Version}, nil
+}
+
+// getAllVersionsOrCached returns the versions of the mirror, or the ones of the last fetch when the mirror is unreachable.
+func getAllVersionsOrCached() (node.Versions, error) {
+	versions, err := node.GetAllVersions()
+
+	if err == nil {
+		return versions, nil
+	}
+
+	cached, cacheErr := node.GetCachedAllVersions()
+
+	if cacheErr != nil || len(cached) == 0 {
+		return nil, errors.WithMessage(err, "failed to get node versions")
+	}
+
+	util.Debug("Failed to get node versions, use the cached ones: %v\n", err)
+
+	return cached, nil
+}
+
+// resolveLocked returns the version locked in the lockfile next to the package.json of the project,
+// or nil when the project has no up to date lockfile and is resolved by its constraint.
+// With --frozen-lockfile, a missing or outdated lockfile is an error.
+func resolveLocked(project *ProjectConstraint) (*Resolution, error) {
+	if project == nil || filepath.Base(project.File) != "package.json" {
+		return nil, nil
+	}
+
+	lockFilePath := filepath.Join(filepath.Dir(project.File), node.LockFileName)
+
+	lock, err := node.ReadLock(lockFilePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lock == nil {
+		if frozen_lockfile {
+			return nil, errors.Errorf("%s not found, run 'nodapt lock' to create it", lockFilePath)
+		}
+
+		return nil, nil
+	}
+
+	if ok, err := version_constraint.Match(project.Constraint, lock.Version); err != nil {
+		return nil, errors.WithStack(err)
+	} else if !ok {
+		if frozen_lockfile {
+			return nil, errors.Errorf("node %s locked in %s does not satisfy %s, run 'nodapt lock' to update it", lock.Version, lockFilePath, project.Constraint)
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: node %s locked in %s does not satisfy %s, run 'nodapt lock' to update it\n", lock.Version, lockFilePath, project.Constraint)
+
+		return nil, nil
+	}
+
+	artifact := lock.Artifact()
+
+	if artifact == nil {
+		if frozen_lockfile {
+			return nil, errors.Errorf("%s has no checksum of node %s for this platform, run 'nodapt lock' to update it", lockFilePath, lock.Version)
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: %s has no checksum of node %s for this platform, run 'nodapt lock' to update it\n", lockFilePath, lock.Version)
+
+		return nil, nil
+	}
+
+	util.Debug("Use node %s locked in %s\n", lock.Version, lockFilePath)
+
+	resolution := &Resolution{Source: SourceRemote, Version: lock.Version, SHA256: artifact.SHA256}
+
+	if cachedNodes, err := node.GetCachedVersions(nodapt_dir); err != nil {
+		return nil, errors.WithStack(err)
+	} else if cache, err := findCachedVersion(cachedNodes, lock.Version); err != nil {
+		return nil, err
+	} else if cache != nil {
+		resolution.Source = SourceInstalled
+		resolution.Path = cache.FilePath
+	}
+
+	return resolution, nil
+}
+
+// resolveProject selects the node that run uses in the current working directory.
+// The project constraint is nil when neither the project nor the default alias specifies one, the system node is used then.
+// A version which is not installed yet is only installed when install is true.
+func resolveProject(install bool) (*ProjectConstraint, *Resolution, error) {
+	return resolveProjectIn("", install)
+}
+
+// resolveProjectIn is like resolveProject for the project in dir, the current working directory when it is empty.
+func resolveProjectIn(dir string, install bool) (*ProjectConstraint, *Resolution, error) {
+	project, err := getProjectConstraintIn(dir)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if project == nil {
+		resolution := &Resolution{Source: SourceSystem}
+
+		if v := node.GetCurrentVersion(); v != nil {
+			resolution.Version = *v
+		}
+
+		return nil, resolution, nil
+	}
+
+	resolution, err := resolveLocked(project)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resolution == nil {
+		if project, resolution, err = resolveProjectConstraint(project); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if resolution.Source == SourceRemote && install {
+		nodeEnvPath, err := node.DownloadVerified(resolution.Version, nodapt_dir, getLockArtifact(resolution.SHA256))
+
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+
+		resolution.Source = SourceInstalled
+		resolution.Path = nodeEnvPath
+	}
+
+	return project, resolution, nil
+}
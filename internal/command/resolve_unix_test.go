@@ -0,0 +1,245 @@
+//go:build unix
+
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupResolve isolates the resolution from the machine: a temporary nodapt directory,
+// a system node of the version in PATH and a mirror serving the versions of the index.
+func setupResolve(t *testing.T, systemVersion string, index string) {
+	t.Helper()
+
+	origNodaptDir, origMirror, origIndexCacheFile, origFrozen := nodapt_dir, node.NODE_MIRROR, node.IndexCacheFile, frozen_lockfile
+
+	t.Cleanup(func() {
+		nodapt_dir, node.NODE_MIRROR, node.IndexCacheFile, frozen_lockfile = origNodaptDir, origMirror, origIndexCacheFile, origFrozen
+	})
+
+	nodapt_dir = t.TempDir()
+	node.IndexCacheFile = ""
+	frozen_lockfile = false
+
+	binDir := t.TempDir()
+
+	if systemVersion != "" {
+		assert.NoError(t, os.WriteFile(filepath.Join(binDir, "node"), []byte("#!/bin/sh\necho "+systemVersion+"\n"), 0755))
+	}
+
+	t.Setenv("PATH", binDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(index))
+	}))
+
+	t.Cleanup(server.Close)
+
+	node.NODE_MIRROR = server.URL + "/"
+}
+
+const resolveIndex = `[
+	{"version": "v22.1.0", "npm": "10.7.0"},
+	{"version": "v20.12.0", "npm": "10.5.0"},
+	{"version": "v18.20.0", "npm": "10.5.0"}
+]`
+
+func TestResolveConstraint(t *testing.T) {
+	tests := []struct {
+		name          string
+		systemVersion string
+		installed     []string
+		constraint    string
+		expected      *Resolution
+		expectError   bool
+	}{
+		{
+			name:          "System node satisfies the constraint",
+			systemVersion: "v20.5.0",
+			installed:     []string{"20.12.0"},
+			constraint:    "^20",
+			expected:      &Resolution{Source: SourceSystem, Version: "v20.5.0"},
+		},
+		{
+			name:          "Newest installed version",
+			systemVersion: "v20.5.0",
+			installed:     []string{"18.19.0", "18.20.0"},
+			constraint:    "^18",
+			expected:      &Resolution{Source: SourceInstalled, Version: "v18.20.0"},
+		},
+		{
+			name:       "Installed version without system node",
+			installed:  []string{"20.12.0"},
+			constraint: "^20",
+			expected:   &Resolution{Source: SourceInstalled, Version: "v20.12.0"},
+		},
+		{
+			name:          "Newest version of the mirror",
+			systemVersion: "v20.5.0",
+			installed:     []string{"18.20.0"},
+			constraint:    "^22",
+			expected:      &Resolution{Source: SourceRemote, Version: "v22.1.0"},
+		},
+		{
+			name:          "No version matches",
+			systemVersion: "v20.5.0",
+			constraint:    "^23",
+			expectError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupResolve(t, tt.systemVersion, resolveIndex)
+
+			paths := map[string]string{}
+
+			for _, version := range tt.installed {
+				paths["v"+version] = installFakeNode(t, nodapt_dir, version)
+			}
+
+			resolution, err := resolveConstraint(tt.constraint, "")
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			tt.expected.Path = paths[tt.expected.Version]
+
+			if tt.expected.Source != SourceInstalled {
+				tt.expected.Path = ""
+			}
+
+			assert.Equal(t, tt.expected, resolution)
+		})
+	}
+}
+
+func TestResolveLocked(t *testing.T) {
+	artifact := node.GetRemoteArtifactTarget("18.20.0")
+
+	if artifact == nil {
+		t.Skip("unsupported platform")
+	}
+
+	checksums := map[string]string{artifact.FullName: "abc123"}
+
+	tests := []struct {
+		name        string
+		lock        *node.Lock
+		installed   bool
+		frozen      bool
+		expected    *Resolution
+		expectError bool
+	}{
+		{
+			name:     "Locked version is downloaded and verified",
+			lock:     node.NewLock("^18", "18.20.0", checksums),
+			expected: &Resolution{Source: SourceRemote, Version: "v18.20.0", SHA256: "abc123"},
+		},
+		{
+			name:      "Locked version is installed",
+			lock:      node.NewLock("^18", "18.20.0", checksums),
+			installed: true,
+			expected:  &Resolution{Source: SourceInstalled, Version: "v18.20.0", SHA256: "abc123"},
+		},
+		{
+			name: "No lockfile",
+		},
+		{
+			name:        "No lockfile with --frozen-lockfile",
+			frozen:      true,
+			expectError: true,
+		},
+		{
+			name: "Outdated lockfile",
+			lock: node.NewLock("^16", "16.20.0", map[string]string{}),
+		},
+		{
+			name:        "Outdated lockfile with --frozen-lockfile",
+			lock:        node.NewLock("^16", "16.20.0", map[string]string{}),
+			frozen:      true,
+			expectError: true,
+		},
+		{
+			name: "No checksum for the platform",
+			lock: node.NewLock("^18", "18.20.0", map[string]string{}),
+		},
+		{
+			name:        "No checksum for the platform with --frozen-lockfile",
+			lock:        node.NewLock("^18", "18.20.0", map[string]string{}),
+			frozen:      true,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupResolve(t, "", resolveIndex)
+
+			frozen_lockfile = tt.frozen
+
+			projectDir := t.TempDir()
+			project := &ProjectConstraint{Constraint: "^18", File: filepath.Join(projectDir, "package.json")}
+
+			if tt.lock != nil {
+				assert.NoError(t, node.WriteLock(filepath.Join(projectDir, node.LockFileName), tt.lock))
+			}
+
+			if tt.installed {
+				tt.expected.Path = installFakeNode(t, nodapt_dir, "18.20.0")
+			}
+
+			resolution, err := resolveLocked(project)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, resolution)
+		})
+	}
+}
+
+func TestWhich(t *testing.T) {
+	setupResolve(t, "", resolveIndex)
+
+	nodeEnvPath := installFakeNode(t, nodapt_dir, "18.20.0")
+
+	pnpmBinDir := filepath.Join(nodapt_dir, "package-managers", "pnpm@9.1.0", "bin")
+
+	assert.NoError(t, os.MkdirAll(pnpmBinDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(pnpmBinDir, "pnpm"), []byte("#!/bin/sh\n"), 0755))
+
+	projectDir := t.TempDir()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(projectDir, "package.json"), []byte(`{"engines": {"node": "^18"}, "packageManager": "pnpm@9.1.0"}`), 0644))
+
+	t.Chdir(projectDir)
+
+	binPath, err := which("node", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(getBinaryDir(nodeEnvPath), "node"), binPath)
+
+	binPath, err = which("pnpm", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(pnpmBinDir, "pnpm"), binPath, "the package manager of the project comes first, as in run")
+
+	_, err = which("yarn", false)
+
+	assert.Error(t, err)
+}
@@ -4,19 +4,19 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"runtime"
-	"sort"
 
 	"github.com/axetroy/nodapt/internal/node"
 	"github.com/axetroy/nodapt/internal/util"
-	"github.com/axetroy/nodapt/internal/version_constraint"
 	"github.com/pkg/errors"
 )
 
 type RunOptions struct {
-	Version string   `json:"version"` // The version of Node.js to use
-	Cmd     []string `json:"cmd"`     // The command to execute
+	Version string            `json:"version"` // The version of Node.js to use, the system node when it is empty
+	Cmd     []string          `json:"cmd"`     // The command to execute
+	Env     map[string]string `json:"env"`     // Additional environment variables of the command
+	SHA256  string            `json:"sha256"`  // The checksum of the archive to verify when it is downloaded
+	BinDirs []string          `json:"binDirs"` // Directories searched before the bin directory of Node.js, e.g. of the package manager
+	Dir     string            `json:"dir"`     // The working directory of the command, defaults to the one of nodapt
 }
 
 // Run executes a command using a specified version of Node.js.
@@ -33,61 +33,98 @@ type RunOptions struct {
 //   - An error if the command fails to execute or if there is an issue
 //     downloading the specified Node.js version; otherwise, it returns nil.
 func run(options *RunOptions) error {
-	if len(options.Cmd) == 0 {
-		return errors.New("no command provided")
-	}
+	process, err := newProcess(options)
 
-	util.Debug("Run command: %s with node %s.\n", options.Cmd, options.Version)
+	if err != nil {
+		return err
+	}
 
-	nodeEnvPath, err := node.Download(options.Version, nodapt_dir)
+	process.Stdin = os.Stdin
+	process.Stdout = os.Stdout
+	process.Stderr = os.Stderr
 
-	if err != nil {
+	if err := execute(process); err != nil {
 		return errors.WithStack(err)
 	}
 
-	var binaryFileDir string
+	return nil
+}
 
-	if runtime.GOOS == "windows" {
-		binaryFileDir = nodeEnvPath
-	} else {
-		binaryFileDir = filepath.Join(nodeEnvPath, "bin")
+// newProcess prepares the process of the command with the node of the options without starting it,
+// the version is downloaded if needed. The standard streams of the process are left unset.
+func newProcess(options *RunOptions) (*exec.Cmd, error) {
+	if len(options.Cmd) == 0 {
+		return nil, errors.New("no command provided")
 	}
 
-	// Check if the node executable exists
-	if ok, err := util.FindExecutable(binaryFileDir, "node"); err != nil {
-		return errors.WithStack(err)
-	} else if !ok {
-		return errors.Errorf("node executable not found in %s, You should try to remove it.", binaryFileDir)
-	}
+	util.Debug("Run command: %s with node %s.\n", options.Cmd, options.Version)
 
-	var process *exec.Cmd
+	var env *util.Env
 
-	command := options.Cmd[0]
+	if options.Version == "" {
+		env = util.NewEnv(os.Environ())
 
-	oldPath := os.Getenv("PATH")
-	oldNpmConfigPrefix := os.Getenv("NPM_CONFIG_PREFIX")
+		for k, v := range options.Env {
+			env.Set(k, v)
+		}
+	} else {
+		nodeEnvPath, err := node.DownloadVerified(options.Version, nodapt_dir, getLockArtifact(options.SHA256))
 
-	defer os.Setenv("PATH", oldPath)
-	defer os.Setenv("NPM_CONFIG_PREFIX", oldNpmConfigPrefix)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
 
-	os.Setenv("PATH", util.AppendEnvPath(binaryFileDir))
-	os.Setenv("NPM_CONFIG_PREFIX", nodeEnvPath)
+		binaryFileDir := getBinaryDir(nodeEnvPath)
 
-	if len(options.Cmd) == 1 {
-		process = exec.Command(command)
-	} else {
-		process = exec.Command(command, options.Cmd[1:]...)
+		// Check if the node executable exists
+		if ok, err := util.FindExecutable(binaryFileDir, "node"); err != nil {
+			return nil, errors.WithStack(err)
+		} else if !ok {
+			return nil, errors.Errorf("node executable not found in %s, You should try to remove it.", binaryFileDir)
+		}
+
+		if err := node.MarkUsed(nodapt_dir, nodeEnvPath); err != nil {
+			util.Debug("Warning: failed to record the usage of %s: %v\n", nodeEnvPath, err)
+		}
+
+		warnAudit(options.Version)
+
+		if prefix := getGlobalPrefix(nodeEnvPath); prefix != nodeEnvPath {
+			warnGlobalsABI(options.Version, prefix)
+		}
+
+		env = newNodeEnv(nodeEnvPath, options.Env)
+
+		// node-gyp and prebuild use the headers instead of downloading them from nodejs.org
+		if headersDir, err := getNodeHeaders(options.Version); err != nil {
+			return nil, err
+		} else if headersDir != "" {
+			env.Set("npm_config_nodedir", headersDir)
+		}
 	}
 
-	process.Stdin = os.Stdin
-	process.Stdout = os.Stdout
-	process.Stderr = os.Stderr
+	for i := len(options.BinDirs) - 1; i >= 0; i-- {
+		env.Set("PATH", options.BinDirs[i]+string(os.PathListSeparator)+env.Get("PATH"))
+	}
 
-	if err := process.Run(); err != nil {
-		return errors.WithStack(err)
+	checkEngines(options.Cmd, options.Dir, env)
+
+	command := options.Cmd[0]
+
+	// Resolve the command with the PATH of the child, not the one of nodapt
+	commandPath, err := env.LookPath(command)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
 
-	return nil
+	process := exec.Command(commandPath, options.Cmd[1:]...)
+
+	process.Args[0] = command
+	process.Env = env.Environ()
+	process.Dir = options.Dir
+
+	return process, nil
 }
 
 // RunWithConstraint executes a command with a specified version constraint.
@@ -105,52 +142,20 @@ func run(options *RunOptions) error {
 // Returns:
 //   - error: Returns an error if the version cannot be matched or if the command fails to execute.md[1:]...)
 func RunWithConstraint(constraint string, command []string) error {
-	installedVersion := node.GetCurrentVersion()
-
-	// If the node version is installed and the version satisfies the constraint, then run the command directly
-	if installedVersion != nil {
-		util.Debug("Current node version: %s\n", *installedVersion)
-		if ok, err := version_constraint.Match(constraint, *installedVersion); err != nil {
-			return errors.WithStack(err)
-		} else if ok {
-			util.Debug("Current node version %s is match the constraint, run command directly.\n", *installedVersion)
-			return RunDirectly(command)
-		}
-	}
-
-	// Found cached node version
-	if cachedNodes, err := node.GetCachedVersions(nodapt_dir); err != nil {
-		return errors.WithStack(err)
-	} else {
-		// Sort versions in descending order
-		sort.Sort(sort.Reverse(node.ByVersion(cachedNodes)))
-
-		for _, node := range cachedNodes {
-			if ok, err := version_constraint.Match(constraint, node.Version); err != nil {
-				return errors.WithStack(err)
-			} else if ok {
-				util.Debug("Found cached node version %s is match the constraint.\n", node.Version)
-				// Found the match version
-				return run(&RunOptions{
-					Version: node.Version,
-					Cmd:     command,
-				})
-			}
-		}
-	}
-
-	matchVersion, err := node.GetMatchVersion(constraint)
+	// The engines.npm of the project only applies to the constraint of the project
+	resolution, err := resolveConstraint(constraint, "")
 
 	if err != nil {
-		return errors.WithMessage(err, "failed to get match version")
+		return err
 	}
 
-	if matchVersion == nil {
-		return errors.Errorf("no match version found for %s", constraint)
+	if resolution.Source == SourceSystem {
+		util.Debug("Run command directly.\n")
+		return RunDirectly(command)
 	}
 
 	return run(&RunOptions{
-		Version: *matchVersion,
+		Version: resolution.Version,
 		Cmd:     command,
 	})
 }
@@ -172,73 +177,101 @@ func RunWithConstraint(constraint string, command []string) error {
 //
 //	error: An error if the command fails to execute, otherwise nil.
 func RunDirectly(cmd []string) error {
-	if len(cmd) == 0 {
-		return errors.New("no command provided")
-	}
+	return runDirectly(cmd, nil)
+}
+
+// runDirectly is like RunDirectly, with the binDirs searched before the PATH of nodapt.
+func runDirectly(cmd []string, binDirs []string) error {
+	process, err := newProcess(&RunOptions{Cmd: cmd, BinDirs: binDirs})
 
-	process := exec.Command(cmd[0], cmd[1:]...)
+	if err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("failed to run command: %s", cmd))
+	}
 
 	process.Stdin = os.Stdin
 	process.Stdout = os.Stdout
 	process.Stderr = os.Stderr
 
-	if err := process.Run(); err != nil {
+	if err := execute(process); err != nil {
 		return errors.WithMessage(err, fmt.Sprintf("failed to run command: %s", cmd))
 	}
 
 	return nil
 }
 
+// Run executes the command with the node constraint of the project in the current working directory,
+// falling back to the default alias. The command runs directly when there is no constraint.
+// The version locked in the lockfile of the project is used when it satisfies the constraint,
+// and the packageManager of package.json comes first in PATH.
 func Run(cmd []string) error {
-	cwd, err := os.Getwd()
+	project, err := getProjectConstraint()
 
 	if err != nil {
-		return errors.WithStack(err)
+		return err
 	}
 
-	if len(cmd) == 0 {
-		packageJSONPath := util.LoopUpFile(cwd, "package.json")
-
-		if packageJSONPath != nil {
-			util.Debug("Use node constraint from %s\n", *packageJSONPath)
-
-			constraint, err := node.GetConstraintFromPackageJSON(*packageJSONPath)
+	if project != nil {
+		util.Debug("Use node constraint %s from %s\n", project.Constraint, project.File)
+	}
 
-			if err != nil {
-				return err
-			}
+	locked, err := resolveLocked(project)
 
-			if constraint != nil {
-				util.Debug("Use node constraint: %s\n", *constraint)
-				return Use(constraint)
-			}
+	if err != nil {
+		return err
+	}
 
-		} else {
+	if len(cmd) == 0 {
+		if project == nil {
 			return errors.New("commands is required")
 		}
-	}
-
-	packageJSONPath := util.LoopUpFile(cwd, "package.json")
 
-	// If the package.json file is found, then use the node constraint in the package.json to run the command
-	if packageJSONPath != nil {
-		util.Debug("Use node constraint from %s\n", *packageJSONPath)
+		if locked != nil {
+			return useResolution(locked)
+		}
 
-		constraint, err := node.GetConstraintFromPackageJSON(*packageJSONPath)
+		_, resolution, err := resolveProjectConstraint(project)
 
 		if err != nil {
-			return errors.WithMessagef(err, "failed to get node constraint from %s", *packageJSONPath)
+			return err
 		}
 
-		if constraint != nil {
-			util.Debug("Use node constraint: %s\n", *constraint)
-			return RunWithConstraint(*constraint, cmd)
-		} else {
-			util.Debug("Run command directly\n")
-			return RunDirectly(cmd)
-		}
-	} else {
+		return useResolution(resolution)
+	}
+
+	binDirs, err := getPackageManagerBinDirs("")
+
+	if err != nil {
+		return err
+	}
+
+	if project == nil {
 		util.Debug("Run command directly\n")
-		return RunDirectly(cmd)
+		return runDirectly(cmd, binDirs)
 	}
+
+	if locked != nil {
+		return run(&RunOptions{
+			Version: locked.Version,
+			Cmd:     cmd,
+			SHA256:  locked.SHA256,
+			BinDirs: binDirs,
+		})
+	}
+
+	_, resolution, err := resolveProjectConstraint(project)
+
+	if err != nil {
+		return err
+	}
+
+	if resolution.Source == SourceSystem {
+		util.Debug("Run command directly.\n")
+		return runDirectly(cmd, binDirs)
+	}
+
+	return run(&RunOptions{
+		Version: resolution.Version,
+		Cmd:     cmd,
+		BinDirs: binDirs,
+	})
 }
@@ -0,0 +1,99 @@
+//go:build unix
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/axetroy/nodapt/internal/node"
+)
+
+// installFakeNode creates a fake installation of the version in dir whose node executable
+// writes the version and its NPM_CONFIG_PREFIX into the file given as the first argument.
+func installFakeNode(t *testing.T, dir string, version string) string {
+	t.Helper()
+
+	artifact := node.GetRemoteArtifactTarget(version)
+
+	if artifact == nil {
+		t.Skip("unsupported platform")
+	}
+
+	nodeEnvPath := filepath.Join(dir, "node", artifact.FileName)
+	binDir := filepath.Join(nodeEnvPath, "bin")
+
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("Failed to create bin dir: %v", err)
+	}
+
+	script := fmt.Sprintf("#!/bin/sh\nsleep 0.2\necho \"v%s $NPM_CONFIG_PREFIX\" > \"$1\"\n", version)
+
+	if err := os.WriteFile(filepath.Join(binDir, "node"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create node executable: %v", err)
+	}
+
+	return nodeEnvPath
+}
+
+func TestRunConcurrently(t *testing.T) {
+	dir := t.TempDir()
+
+	origNodaptDir := nodapt_dir
+	defer func() { nodapt_dir = origNodaptDir }()
+	nodapt_dir = dir
+
+	versions := []string{"18.20.0", "20.11.1"}
+	nodeEnvPaths := map[string]string{}
+
+	for _, version := range versions {
+		nodeEnvPaths[version] = installFakeNode(t, dir, version)
+	}
+
+	origPath := os.Getenv("PATH")
+	origPrefix := os.Getenv("NPM_CONFIG_PREFIX")
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(versions))
+
+	for i, version := range versions {
+		wg.Add(1)
+
+		go func(i int, version string) {
+			defer wg.Done()
+
+			errs[i] = run(&RunOptions{
+				Version: "v" + version,
+				Cmd:     []string{"node", filepath.Join(dir, version+".txt")},
+			})
+		}(i, version)
+	}
+
+	wg.Wait()
+
+	for i, version := range versions {
+		if errs[i] != nil {
+			t.Fatalf("run() with %s error = %v", version, errs[i])
+		}
+
+		output, err := os.ReadFile(filepath.Join(dir, version+".txt"))
+
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+
+		expected := fmt.Sprintf("v%s %s", version, nodeEnvPaths[version])
+
+		if strings.TrimSpace(string(output)) != expected {
+			t.Errorf("expected %q, got %q", expected, strings.TrimSpace(string(output)))
+		}
+	}
+
+	if os.Getenv("PATH") != origPath || os.Getenv("NPM_CONFIG_PREFIX") != origPrefix {
+		t.Errorf("the environment of the current process should not be modified")
+	}
+}
@@ -0,0 +1,182 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/axetroy/nodapt/internal/version_constraint"
+	"github.com/pkg/errors"
+)
+
+type UpgradeOptions struct {
+	Constraint     *string // Only upgrade the installed versions which satisfy the constraint
+	Prune          bool    // Remove the versions superseded by the upgrade
+	MigrateGlobals bool    // Reinstall the global packages of the newest superseded version into the new one
+}
+
+// upgradePlan is the upgrade of the installed versions of a major.
+type upgradePlan struct {
+	Major     uint64
+	Installed []node.CachedNode // Sorted in ascending order
+	Target    string            // The newest matching release, empty if the newest installed version is up to date
+}
+
+// planUpgrades groups the installed versions matching the constraint by major,
+// and finds the newest release of every major which also matches the constraint.
+// The remote versions are in the order of the index, newest first.
+func planUpgrades(cachedNodes []node.CachedNode, remote node.Versions, constraint *string) ([]upgradePlan, error) {
+	groups := map[uint64][]node.CachedNode{}
+
+	for _, cache := range cachedNodes {
+		v, err := semver.NewVersion(cache.Version)
+
+		if err != nil {
+			util.Debug("Skip the invalid version %s: %v\n", cache.Version, err)
+			continue
+		}
+
+		if constraint != nil {
+			if ok, err := version_constraint.Match(*constraint, cache.Version); err != nil {
+				return nil, errors.WithStack(err)
+			} else if !ok {
+				continue
+			}
+		}
+
+		groups[v.Major()] = append(groups[v.Major()], cache)
+	}
+
+	plans := make([]upgradePlan, 0, len(groups))
+
+	for major, installed := range groups {
+		sort.Sort(node.ByVersion(installed))
+
+		plan := upgradePlan{Major: major, Installed: installed}
+		newest := semver.MustParse(installed[len(installed)-1].Version)
+
+		for _, r := range remote {
+			v, err := semver.NewVersion(r.Version)
+
+			if err != nil || v.Major() != major {
+				continue
+			}
+
+			if constraint != nil {
+				if ok, err := version_constraint.Match(*constraint, r.Version); err != nil {
+					return nil, errors.WithStack(err)
+				} else if !ok {
+					continue
+				}
+			}
+
+			if v.GreaterThan(newest) {
+				plan.Target = r.Version
+			}
+
+			break
+		}
+
+		plans = append(plans, plan)
+	}
+
+	sort.Slice(plans, func(i, j int) bool { return plans[i].Major < plans[j].Major })
+
+	return plans, nil
+}
+
+// Upgrade installs the newest release of every installed major, or of the majors matching the constraint.
+func Upgrade(options *UpgradeOptions) error {
+	if options.Constraint != nil {
+		constraint, err := expandAlias(*options.Constraint)
+
+		if err != nil {
+			return err
+		}
+
+		options.Constraint = &constraint
+	}
+
+	cachedNodes, err := node.GetCachedVersions(nodapt_dir)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	remote, err := node.GetAllVersions()
+
+	if err != nil {
+		return errors.WithMessage(err, "failed to get node versions")
+	}
+
+	plans, err := planUpgrades(cachedNodes, remote, options.Constraint)
+
+	if err != nil {
+		return err
+	}
+
+	if len(plans) == 0 {
+		fmt.Fprintf(os.Stderr, "No installed node version to upgrade\n")
+		return nil
+	}
+
+	project, err := getProjectConstraint()
+
+	if err != nil {
+		return err
+	}
+
+	for _, plan := range plans {
+		newest := plan.Installed[len(plan.Installed)-1]
+
+		if plan.Target == "" {
+			fmt.Fprintf(os.Stderr, "Node version %s is up to date\n", newest.Version)
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "Upgrading node %s to %s\n", newest.Version, plan.Target)
+
+		nodeEnvPath, err := node.Download(plan.Target, nodapt_dir)
+
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if options.MigrateGlobals {
+			if err := migrateGlobals(newest.FilePath, nodeEnvPath); err != nil {
+				return errors.WithMessagef(err, "failed to migrate the global packages of %s, superseded versions are kept", newest.Version)
+			}
+		}
+
+		if !options.Prune {
+			continue
+		}
+
+		for _, cache := range plan.Installed {
+			// Keep the version the project still requires
+			if project != nil {
+				if ok, err := version_constraint.Match(project.Constraint, plan.Target); err != nil {
+					return errors.WithStack(err)
+				} else if !ok {
+					if required, err := version_constraint.Match(project.Constraint, cache.Version); err != nil {
+						return errors.WithStack(err)
+					} else if required {
+						fmt.Fprintf(os.Stderr, "Keep node version %s required by %s\n", cache.Version, project.File)
+						continue
+					}
+				}
+			}
+
+			if err := node.RemoveCached(nodapt_dir, cache); err != nil {
+				return errors.WithStack(err)
+			}
+
+			fmt.Fprintf(os.Stderr, "Node version %s has been removed\n", cache.Version)
+		}
+	}
+
+	return nil
+}
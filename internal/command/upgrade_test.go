@@ -0,0 +1,80 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanUpgrades(t *testing.T) {
+	cached := func(versions ...string) []node.CachedNode {
+		result := make([]node.CachedNode, 0, len(versions))
+		for _, v := range versions {
+			result = append(result, node.CachedNode{Version: v, FilePath: "/nodapt/node/node-" + v})
+		}
+		return result
+	}
+
+	remote := node.Versions{
+		{Version: "v22.1.0"},
+		{Version: "v20.12.0"},
+		{Version: "v20.11.1"},
+		{Version: "v20.11.0"},
+		{Version: "v18.20.0"},
+		{Version: "v16.20.2"},
+	}
+
+	summary := func(plans []upgradePlan) map[uint64]string {
+		result := map[uint64]string{}
+		for _, p := range plans {
+			result[p.Major] = p.Target
+		}
+		return result
+	}
+
+	constraint := func(c string) *string { return &c }
+
+	tests := []struct {
+		name       string
+		installed  []node.CachedNode
+		constraint *string
+		expected   map[uint64]string
+	}{
+		{
+			name:      "Upgrade every installed major",
+			installed: cached("v20.11.0", "v18.20.0", "v20.10.0", "v16.0.0"),
+			expected:  map[uint64]string{16: "v16.20.2", 18: "", 20: "v20.12.0"},
+		},
+		{
+			name:       "Only the majors matching the constraint",
+			installed:  cached("v20.11.0", "v16.0.0"),
+			constraint: constraint("^20"),
+			expected:   map[uint64]string{20: "v20.12.0"},
+		},
+		{
+			name:       "The target matches the constraint",
+			installed:  cached("v20.11.0"),
+			constraint: constraint("~20.11.0"),
+			expected:   map[uint64]string{20: "v20.11.1"},
+		},
+		{
+			name:      "Nothing installed",
+			installed: cached(),
+			expected:  map[uint64]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plans, err := planUpgrades(tt.installed, remote, tt.constraint)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, summary(plans))
+		})
+	}
+
+	plans, err := planUpgrades(cached("v20.11.0", "v20.10.0"), remote, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, cached("v20.10.0", "v20.11.0"), plans[0].Installed, "installed versions are sorted")
+}
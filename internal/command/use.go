@@ -2,9 +2,6 @@ package command
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-	"runtime"
 
 	"github.com/axetroy/nodapt/internal/crosspty"
 	"github.com/axetroy/nodapt/internal/node"
@@ -13,46 +10,53 @@ import (
 	"github.com/pkg/errors"
 )
 
-func Use(constraint *string) error {
-	if constraint == nil {
-		cwd, err := os.Getwd()
+// Use starts a new shell with the node selected for the constraint the same way run does.
+// The engines.npm of the project is ignored, as the constraint does not come from it.
+func Use(constraint string) error {
+	resolution, err := resolveConstraint(constraint, "")
 
-		if err != nil {
-			return errors.WithStack(err)
-		}
+	if err != nil {
+		return err
+	}
 
-		packageJSONPath := util.LoopUpFile(cwd, "package.json")
+	util.Debug("Use node %s from %s\n", resolution.Version, resolution.Source)
 
-		// If the package.json file is found, then use the node constraint in the package.json to run the command
-		if packageJSONPath != nil {
-			util.Debug("Use node constraint from %s\n", *packageJSONPath)
+	return useResolution(resolution)
+}
 
-			c, err := node.GetConstraintFromPackageJSON(*packageJSONPath)
+// useResolution starts a new shell with the node of the resolution, the system node is kept as is.
+func useResolution(resolution *Resolution) error {
+	if resolution.Source == SourceSystem {
+		return useSystem(resolution.Version)
+	}
 
-			if err != nil {
-				return errors.WithMessagef(err, "failed to get node constraint from %s", *packageJSONPath)
-			}
+	return useVersion(resolution.Version, getLockArtifact(resolution.SHA256))
+}
 
-			constraint = c
-		}
+// useSystem starts a new shell with the node found in PATH, whose version may be unknown.
+func useSystem(version string) error {
+	shellPath, err := shell.GetPath()
+	if err != nil {
+		return errors.WithMessage(err, "Cannot find shell")
 	}
 
-	if constraint == nil {
-		return errors.New("constraint is required")
-	}
+	util.Debug("Current shell: %s\n", shellPath)
 
-	util.Debug("Use constraint: %s\n", *constraint)
+	welcome := "nodapt shell initialized with the system Node.js, Type 'exit' to exit."
 
-	version, err := node.GetMatchVersion(*constraint)
+	if version != "" {
+		welcome = fmt.Sprintf("nodapt shell initialized with the system Node.js %s, Type 'exit' to exit.", version)
+	}
 
-	if err != nil {
+	if err := crosspty.Start(shellPath, map[string]string{}, welcome); err != nil {
 		return errors.WithStack(err)
 	}
 
-	if version == nil {
-		return errors.Errorf("Cannot find the version of node which matches the constraint: %s", *constraint)
-	}
+	return nil
+}
 
+// useVersion starts a new shell with the version, the archive of the artifact is downloaded and verified if it is not nil.
+func useVersion(version string, artifact *node.LockArtifact) error {
 	shellPath, err := shell.GetPath()
 	if err != nil {
 		return errors.WithMessage(err, "Cannot find shell")
@@ -60,33 +64,36 @@ func Use(constraint *string) error {
 
 	util.Debug("Current shell: %s\n", shellPath)
 
-	nodePath, err := node.Download(*version, nodapt_dir)
+	nodePath, err := node.DownloadVerified(version, nodapt_dir, artifact)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	var binaryFileDir string
-	if runtime.GOOS == "windows" {
-		binaryFileDir = nodePath
-	} else {
-		binaryFileDir = filepath.Join(nodePath, "bin")
+	if err := node.MarkUsed(nodapt_dir, nodePath); err != nil {
+		util.Debug("Warning: failed to record the usage of %s: %v\n", nodePath, err)
 	}
 
-	oldPath := os.Getenv("PATH")
-	oldNpmConfigPrefix := os.Getenv("NPM_CONFIG_PREFIX")
+	warnAudit(version)
 
-	// 设置新的 PATH 变量
-	os.Setenv("PATH", util.AppendEnvPath(binaryFileDir))
-	os.Setenv("NPM_CONFIG_PREFIX", nodePath)
+	if prefix := getGlobalPrefix(nodePath); prefix != nodePath {
+		warnGlobalsABI(version, prefix)
+	}
+
+	nodeEnv := newNodeEnv(nodePath, nil)
 
-	// Defer restoration AFTER setting new values to ensure cleanup happens properly
-	defer os.Setenv("PATH", oldPath)
-	defer os.Setenv("NPM_CONFIG_PREFIX", oldNpmConfigPrefix)
+	env := map[string]string{
+		"NPM_CONFIG_PREFIX": nodeEnv.Get("NPM_CONFIG_PREFIX"),
+		"PATH":              nodeEnv.Get("PATH"),
+	}
+
+	// node-gyp and prebuild use the headers instead of downloading them from nodejs.org
+	if headersDir, err := getNodeHeaders(version); err != nil {
+		return err
+	} else if headersDir != "" {
+		env["npm_config_nodedir"] = headersDir
+	}
 
-	if err := crosspty.Start(shellPath, map[string]string{
-		"NPM_CONFIG_PREFIX": os.Getenv("NPM_CONFIG_PREFIX"),
-		"PATH":              os.Getenv("PATH"),
-	}, fmt.Sprintf("nodapt shell initialized with Node.js %s, Type 'exit' to exit.", *version)); err != nil {
+	if err := crosspty.Start(shellPath, env, fmt.Sprintf("nodapt shell initialized with Node.js %s, Type 'exit' to exit.", version)); err != nil {
 		return errors.WithStack(err)
 	}
 
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+type WhichOptions struct {
+	Install bool // Install the selected version if it is not installed yet
+}
+
+// Which prints the absolute path of the executable bin, e.g. node, npm or the binary of a global package,
+// of the node version that run uses in the current working directory.
+func Which(bin string, options *WhichOptions) error {
+	binPath, err := which(bin, options.Install)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(binPath)
+
+	return nil
+}
+
+// which returns the absolute path of the executable bin that run executes in the current working directory.
+func which(bin string, install bool) (string, error) {
+	_, resolution, err := resolveProject(install)
+
+	if err != nil {
+		return "", err
+	}
+
+	binDirs, err := getPackageManagerBinDirs("")
+
+	if err != nil {
+		return "", err
+	}
+
+	var env *util.Env
+
+	switch resolution.Source {
+	case SourceSystem:
+		env = util.NewEnv(os.Environ())
+	case SourceInstalled:
+		// Only look inside the version and its global packages, the same order as run
+		paths := getBinaryDir(resolution.Path)
+
+		if prefix := getGlobalPrefix(resolution.Path); prefix != resolution.Path {
+			paths += string(os.PathListSeparator) + getBinaryDir(prefix)
+		}
+
+		env = util.NewEnv([]string{"PATH=" + paths})
+	default:
+		return "", errors.Errorf("node %s is not installed, run with --install to install it", resolution.Version)
+	}
+
+	// The package manager of the project comes first, as in run
+	if len(binDirs) > 0 {
+		env.Set("PATH", strings.Join(binDirs, string(os.PathListSeparator))+string(os.PathListSeparator)+env.Get("PATH"))
+	}
+
+	binPath, err := env.LookPath(bin)
+
+	if err != nil {
+		return "", errors.Errorf("%s not found in node %s", bin, resolution.Version)
+	}
+
+	return binPath, nil
+}
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"path/filepath"
+
+	"github.com/axetroy/nodapt/internal/node"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/axetroy/nodapt/internal/version_constraint"
+	"github.com/axetroy/nodapt/internal/workspace"
+	"github.com/pkg/errors"
+)
+
+// Intersect the node constraint of a workspace package with the one of the workspace root
+var workspace_intersect bool
+
+// SetWorkspaceIntersect makes a workspace package satisfy the node constraints of both itself and the workspace root.
+func SetWorkspaceIntersect(intersect bool) {
+	workspace_intersect = intersect
+}
+
+// isWorkspaceIntersect reports whether --intersect-workspace or NODAPT_WORKSPACE_INTERSECT=1 is set.
+func isWorkspaceIntersect() bool {
+	return workspace_intersect || util.GetEnvsWithFallback("", "NODAPT_WORKSPACE_INTERSECT") == "1"
+}
+
+// getWorkspaceRootPackageJSON returns the package.json of the root of the workspace which contains the package,
+// or an empty string if the package is not in a workspace, is the root itself or the root has no package.json.
+func getWorkspaceRootPackageJSON(packageJSONPath string) (string, error) {
+	packageDir := filepath.Dir(packageJSONPath)
+
+	ws, err := workspace.Find(packageDir)
+
+	if err != nil {
+		return "", errors.WithMessagef(err, "failed to find the workspace of %s", packageDir)
+	}
+
+	if ws == nil {
+		return "", nil
+	}
+
+	if absDir, err := filepath.Abs(packageDir); err != nil {
+		return "", errors.WithStack(err)
+	} else if absDir == ws.Root {
+		return "", nil
+	}
+
+	rootPackageJSONPath := filepath.Join(ws.Root, "package.json")
+
+	if !util.IsFileExist(rootPackageJSONPath) {
+		return "", nil
+	}
+
+	util.Debug("Found workspace root %s declared in %s\n", ws.Root, ws.File)
+
+	return rootPackageJSONPath, nil
+}
+
+// intersectWorkspaceConstraint returns the constraint satisfied by both the package and the workspace root.
+// It fails when no known version of node satisfies both.
+func intersectWorkspaceConstraint(project *ProjectConstraint, root *ProjectConstraint) (*ProjectConstraint, error) {
+	constraint, err := version_constraint.Intersect(project.Constraint, root.Constraint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := getAllVersionsOrCached()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if match, err := findRemoteVersion(versions, constraint, ""); err != nil {
+		return nil, err
+	} else if match == nil {
+		return nil, errors.Errorf("%s %s of %s and %s %s of the workspace root %s have no version of node in common",
+			project.Field, project.Constraint, project.File, root.Field, root.Constraint, root.File)
+	}
+
+	util.Debug("Intersect node constraint %s with %s of the workspace root\n", project.Constraint, root.Constraint)
+
+	return &ProjectConstraint{
+		Constraint: constraint,
+		File:       project.File,
+		Field:      project.Field,
+		Npm:        project.Npm,
+		OnFail:     node.OnFailError,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package crosspty
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// newEnv returns the environment of nodapt with the variables in env overriding the existing ones.
+func newEnv(env map[string]string) []string {
+	e := util.NewEnv(os.Environ())
+
+	for k, v := range env {
+		e.Set(k, v)
+	}
+
+	return e.Environ()
+}
+
+// getScriptArgs returns the arguments that make the shell execute the script read from stdin.
+// Shells that cannot read a script from stdin get the whole script through their "-c" flag instead,
+// in which case readScript is true.
+func getScriptArgs(shellName string) (args []string, readScript bool) {
+	switch shellName {
+	case "powershell", "powershell.exe", "pwsh", "pwsh.exe":
+		return []string{"-NoLogo", "-NonInteractive", "-Command", "-"}, false
+	case "cmd", "cmd.exe":
+		return []string{"/Q"}, false
+	case "nu", "elvish":
+		return []string{"-c"}, true
+	default:
+		return []string{}, false
+	}
+}
+
+// startPlain runs the shell as a plain child process with inherited stdio.
+// It is used when stdin is not a terminal (e.g. CI, pipes or heredocs),
+// so `echo 'node -v' | nodapt use 20` runs the piped commands as a script.
+// Termination signals are forwarded to the shell and its commands as with the pty.
+func startPlain(shellPath string, shellName string, env map[string]string) error {
+	args, readScript := getScriptArgs(shellName)
+
+	if readScript {
+		script, err := io.ReadAll(os.Stdin)
+
+		if err != nil {
+			return errors.WithMessage(err, "failed to read script from stdin")
+		}
+
+		args = append(args, string(script))
+	}
+
+	c := exec.Command(shellPath, args...)
+
+	c.Env = newEnv(env)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	if !readScript {
+		c.Stdin = os.Stdin
+	}
+
+	setProcessGroup(c)
+
+	// Register before starting the shell, so no termination signal is missed or kills nodapt in between
+	sigCh := make(chan os.Signal, 1)
+
+	signal.Notify(sigCh, terminationSignals...)
+
+	// No signal is delivered to the channel once Stop returns, so it is safe to close it
+	defer func() { signal.Stop(sigCh); close(sigCh) }()
+
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	// Forward termination signals sent to nodapt to the shell
+	go forwardSignals(sigCh, c.Process)
+
+	return c.Wait()
+}
@@ -0,0 +1,97 @@
+//go:build unix
+
+package crosspty
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStartPlain(t *testing.T) {
+	shellPath, err := exec.LookPath("sh")
+
+	if err != nil {
+		t.Skip("sh is not available")
+	}
+
+	output := filepath.Join(t.TempDir(), "output.txt")
+
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+
+	origStdin := os.Stdin
+	defer func() { os.Stdin = origStdin }()
+	os.Stdin = r
+
+	_, _ = w.WriteString("echo \"$NODAPT_TEST_VALUE\" > '" + output + "'\n")
+	w.Close()
+
+	if err := Start(shellPath, map[string]string{"NODAPT_TEST_VALUE": "it's ok"}, "welcome"); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+
+	if strings.TrimSpace(string(content)) != "it's ok" {
+		t.Errorf("expected %q, got %q", "it's ok", string(content))
+	}
+}
+
+func TestStartPlainForwardsSignals(t *testing.T) {
+	shellPath, err := exec.LookPath("sh")
+
+	if err != nil {
+		t.Skip("sh is not available")
+	}
+
+	output := filepath.Join(t.TempDir(), "output.txt")
+
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+
+	origStdin := os.Stdin
+	defer func() { os.Stdin = origStdin }()
+	os.Stdin = r
+
+	// The background command outlives the shell unless the signal reaches it as well
+	_, _ = w.WriteString("(sleep 1; echo orphaned > '" + output + "') &\nwait\n")
+	w.Close()
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+
+	err = Start(shellPath, map[string]string{}, "welcome")
+
+	exitErr, ok := err.(*exec.ExitError)
+
+	if !ok {
+		t.Fatalf("Start() error = %v, expected the shell to be terminated", err)
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); !ok || !status.Signaled() || status.Signal() != syscall.SIGTERM {
+		t.Errorf("expected the shell to be terminated by SIGTERM, got %v", exitErr)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	if _, err := os.Stat(output); err == nil {
+		t.Errorf("expected the commands of the shell to be terminated as well")
+	}
+}
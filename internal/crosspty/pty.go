@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"strings"
@@ -25,14 +26,108 @@ func setPytSize(p pty.Pty) error {
 
 func getNewLine(shellName string) string {
 	switch shellName {
-	case "cmd", "cmd.exe", "powershell", "powershell.exe":
+	case "cmd", "cmd.exe", "powershell", "powershell.exe", "pwsh.exe":
 		return "\r\n"
+	case "pwsh", "nu", "elvish", "xonsh":
+		// These shells put the terminal into raw mode and only treat carriage return as Enter
+		return "\r"
 	default:
 		return "\n"
 	}
 }
 
+// getSetEnvCommand returns the command that sets the environment variable key to value
+// in the given shell, with the value quoted according to the shell's syntax.
+func getSetEnvCommand(shellName string, key string, value string) string {
+	switch shellName {
+	case "bash", "zsh":
+		// Escape single quotes for bash/zsh: replace ' with '"'"'
+		escapedValue := strings.Replace(value, "'", "'\"'\"'", -1)
+		return fmt.Sprintf("export %s='%s'", key, escapedValue)
+	case "fish":
+		// Escape single quotes for fish: replace ' with '"'"'
+		escapedValue := strings.Replace(value, "'", "'\"'\"'", -1)
+		return fmt.Sprintf("set -gx %s '%s'", key, escapedValue)
+	case "powershell", "powershell.exe", "pwsh", "pwsh.exe":
+		// For PowerShell, escape single quotes by doubling them: ' becomes ''
+		escapedValue := strings.Replace(value, "'", "''", -1)
+		return fmt.Sprintf("$env:%s='%s'", key, escapedValue)
+	case "cmd", "cmd.exe":
+		// For CMD, wrap in quotes and escape quotes: " becomes ""
+		// Also escape special chars like &, |, <, >, ^, %, !
+		escapedValue := strings.NewReplacer(
+			"\"", "\"\"",
+			"&", "^&",
+			"|", "^|",
+			"<", "^<",
+			">", "^>",
+			"^", "^^",
+			"%", "%%",
+		).Replace(value)
+		return fmt.Sprintf("set \"%s=%s\"", key, escapedValue)
+	case "nu":
+		// Nushell double-quoted strings support backslash escapes
+		escapedValue := strings.NewReplacer(
+			"\\", "\\\\",
+			"\"", "\\\"",
+			"\n", "\\n",
+			"\r", "\\r",
+			"\t", "\\t",
+		).Replace(value)
+		// PATH is a list in nushell, so split the value by the path separator
+		if key == "PATH" || key == "Path" {
+			return fmt.Sprintf("$env.%s = (\"%s\" | split row (char esep))", key, escapedValue)
+		}
+		return fmt.Sprintf("$env.%s = \"%s\"", key, escapedValue)
+	case "elvish":
+		// For Elvish, escape single quotes by doubling them: ' becomes ''
+		escapedValue := strings.Replace(value, "'", "''", -1)
+		return fmt.Sprintf("set-env %s '%s'", key, escapedValue)
+	case "xonsh":
+		// Xonsh uses Python string literals: escape backslashes and single quotes
+		escapedValue := strings.NewReplacer(
+			"\\", "\\\\",
+			"'", "\\'",
+			"\n", "\\n",
+			"\r", "\\r",
+		).Replace(value)
+		return fmt.Sprintf("$%s = '%s'", key, escapedValue)
+	case "tcsh", "csh":
+		// Single quotes cannot be escaped inside single quotes in csh: replace ' with '\''
+		// History expansion still happens inside single quotes, so ! must be escaped too
+		escapedValue := strings.NewReplacer(
+			"'", "'\\''",
+			"!", "\\!",
+		).Replace(value)
+		return fmt.Sprintf("setenv %s '%s'", key, escapedValue)
+	default:
+		// Default to bash-style escaping
+		escapedValue := strings.Replace(value, "'", "'\"'\"'", -1)
+		return fmt.Sprintf("export %s='%s'", key, escapedValue)
+	}
+}
+
+// getClearCommand returns the command that clears the screen in the given shell.
+func getClearCommand(shellName string) string {
+	switch shellName {
+	case "powershell", "powershell.exe", "pwsh", "pwsh.exe":
+		return "Clear-Host"
+	case "cmd", "cmd.exe":
+		return "cls"
+	default:
+		return "clear"
+	}
+}
+
 func Start(shellPath string, env map[string]string, welcome string) error {
+	shellName := filepath.Base(shellPath)
+
+	// Without a terminal there is nothing to attach the pty to, run the shell as a script interpreter instead
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		util.Debug("stdin is not a terminal, run %s non-interactively\n", shellPath)
+		return startPlain(shellPath, shellName, env)
+	}
+
 	if _, err := os.Stderr.WriteString(welcome + "\n"); err != nil {
 		// Non-fatal, just log the error
 		util.Debug("Warning: failed to write welcome message: %v\n", err)
@@ -45,11 +140,25 @@ func Start(shellPath string, env map[string]string, welcome string) error {
 
 	defer ptmx.Close()
 
+	// Register before starting the shell, so no termination signal is missed or kills nodapt in between
+	sigCh := make(chan os.Signal, 1)
+
+	signal.Notify(sigCh, terminationSignals...)
+
+	// No signal is delivered to the channel once Stop returns, so it is safe to close it
+	defer func() { signal.Stop(sigCh); close(sigCh) }()
+
 	c := ptmx.Command(shellPath)
+	c.Env = newEnv(env)
 	if err := c.Start(); err != nil {
 		return err
 	}
 
+	// Forward termination signals sent to nodapt to the shell
+	go forwardSignals(sigCh, c.Process)
+
+	releaseTerminal(ptmx)
+
 	if err := setPytSize(ptmx); err != nil {
 		return errors.WithMessage(err, "failed to set initial pty size")
 	}
@@ -71,64 +180,15 @@ func Start(shellPath string, env map[string]string, welcome string) error {
 
 	time.Sleep(1000 * time.Millisecond) // Give the shell some time to start.
 
-	// Copy stdin to the pty and the pty to stdout.
-	// NOTE: The goroutine will keep reading until the next keystroke before returning.
-	shellName := filepath.Base(shellPath)
-
 	newLine := getNewLine(shellName)
 
 	// Set environment variables
 	for k, v := range env {
-		var command string
-
-		switch shellName {
-		case "bash", "zsh":
-			// Escape single quotes for bash/zsh: replace ' with '"'"'
-			escapedValue := strings.Replace(v, "'", "'\"'\"'", -1)
-			command = fmt.Sprintf("export %s='%s'", k, escapedValue) + newLine
-		case "fish":
-			// Escape single quotes for fish: replace ' with '"'"'
-			escapedValue := strings.Replace(v, "'", "'\"'\"'", -1)
-			command = fmt.Sprintf("set -gx %s '%s'", k, escapedValue) + newLine
-		case "powershell", "powershell.exe":
-			// For PowerShell, escape single quotes by doubling them: ' becomes ''
-			escapedValue := strings.Replace(v, "'", "''", -1)
-			command = fmt.Sprintf("$env:%s='%s'", k, escapedValue) + newLine
-		case "cmd", "cmd.exe":
-			// For CMD, wrap in quotes and escape quotes: " becomes ""
-			// Also escape special chars like &, |, <, >, ^, %, !
-			escapedValue := strings.NewReplacer(
-				"\"", "\"\"",
-				"&", "^&",
-				"|", "^|",
-				"<", "^<",
-				">", "^>",
-				"^", "^^",
-				"%", "%%",
-			).Replace(v)
-			command = fmt.Sprintf("set \"%s=%s\"", k, escapedValue) + newLine
-		default:
-			// Default to bash-style escaping
-			escapedValue := strings.Replace(v, "'", "'\"'\"'", -1)
-			command = fmt.Sprintf("export %s='%s'", k, escapedValue) + newLine
-		}
-
-		_, _ = ptmx.Write([]byte(command))
+		_, _ = ptmx.Write([]byte(getSetEnvCommand(shellName, k, v) + newLine))
 	}
 
 	// Clear the screen
-	switch shellName {
-	case "bash", "zsh":
-		_, _ = ptmx.Write([]byte("clear" + newLine))
-	case "fish":
-		_, _ = ptmx.Write([]byte("clear" + newLine))
-	case "powershell", "powershell.exe":
-		_, _ = ptmx.Write([]byte("Clear-Host" + newLine))
-	case "cmd", "cmd.exe":
-		_, _ = ptmx.Write([]byte("cls" + newLine))
-	default:
-		_, _ = ptmx.Write([]byte("clear" + newLine))
-	}
+	_, _ = ptmx.Write([]byte(getClearCommand(shellName) + newLine))
 
 	// Clear the PTY output buffer with a timeout to prevent blocking
 	// Use channels to coordinate goroutine cleanup
@@ -165,13 +225,44 @@ func Start(shellPath string, env map[string]string, welcome string) error {
 
 	_, _ = ptmx.Write([]byte(newLine))
 
+	// Copy stdin to the pty and the pty to stdout.
+	exited := make(chan struct{})
+	inputDone := make(chan struct{})
+	outputDone := make(chan struct{})
+
 	go func() {
-		_, _ = io.Copy(ptmx, os.Stdin)
+		defer close(inputDone)
+		copyInput(ptmx, exited)
 	}()
 
 	go func() {
+		defer close(outputDone)
 		_, _ = io.Copy(os.Stdout, ptmx)
 	}()
 
-	return c.Wait()
+	err = c.Wait()
+
+	close(exited)
+
+	// Flush the remaining output, background jobs may keep the terminal open so don't wait forever
+	select {
+	case <-outputDone:
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	_ = ptmx.Close()
+
+	<-outputDone
+	<-inputDone
+
+	if err != nil {
+		return err
+	}
+
+	// Not every platform reports a non-zero exit code as an error
+	if c.ProcessState != nil && !c.ProcessState.Success() {
+		return &exec.ExitError{ProcessState: c.ProcessState}
+	}
+
+	return nil
 }
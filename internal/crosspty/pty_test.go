@@ -0,0 +1,61 @@
+package crosspty
+
+import (
+	"testing"
+)
+
+func TestGetSetEnvCommand(t *testing.T) {
+	tests := []struct {
+		shellName string
+		key       string
+		value     string
+		expected  string
+	}{
+		{"bash", "FOO", "bar", `export FOO='bar'`},
+		{"zsh", "FOO", "it's", `export FOO='it'"'"'s'`},
+		{"sh", "FOO", "it's", `export FOO='it'"'"'s'`},
+		{"fish", "FOO", "it's", `set -gx FOO 'it'"'"'s'`},
+		{"powershell.exe", "FOO", "it's", `$env:FOO='it''s'`},
+		{"pwsh", "FOO", "it's", `$env:FOO='it''s'`},
+		{"cmd.exe", "FOO", `a&b|"c"%`, `set "FOO=a^&b^|""c""%%"`},
+		{"nu", "FOO", `say "hi" \ $HOME`, `$env.FOO = "say \"hi\" \\ $HOME"`},
+		{"nu", "FOO", "line1\nline2", `$env.FOO = "line1\nline2"`},
+		{"nu", "PATH", "/a:/b", `$env.PATH = ("/a:/b" | split row (char esep))`},
+		{"elvish", "FOO", "it's $x", `set-env FOO 'it''s $x'`},
+		{"xonsh", "FOO", `it's C:\dir`, `$FOO = 'it\'s C:\\dir'`},
+		{"tcsh", "FOO", "it's!", `setenv FOO 'it'\''s\!'`},
+		{"csh", "PATH", "/a b:/c", `setenv PATH '/a b:/c'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shellName+"_"+tt.key, func(t *testing.T) {
+			result := getSetEnvCommand(tt.shellName, tt.key, tt.value)
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetClearCommand(t *testing.T) {
+	tests := []struct {
+		shellName string
+		expected  string
+	}{
+		{"bash", "clear"},
+		{"nu", "clear"},
+		{"tcsh", "clear"},
+		{"pwsh", "Clear-Host"},
+		{"powershell.exe", "Clear-Host"},
+		{"cmd.exe", "cls"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shellName, func(t *testing.T) {
+			result := getClearCommand(tt.shellName)
+			if result != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
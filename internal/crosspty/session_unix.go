@@ -0,0 +1,84 @@
+//go:build !windows
+
+package crosspty
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/aymanbagabas/go-pty"
+	"golang.org/x/sys/unix"
+)
+
+// releaseTerminal closes the parent's copy of the slave end of the pty once the shell has started,
+// so reading from the master returns an error as soon as the shell and its children have exited.
+func releaseTerminal(p pty.Pty) {
+	if up, ok := p.(pty.UnixPty); ok {
+		_ = up.Slave().Close()
+	}
+}
+
+// setProcessGroup starts the shell of the command in a new process group,
+// so forwardSignals reaches the commands it runs as well, as with the pty where the shell leads a session.
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminationSignals are the signals forwarded to the shell.
+var terminationSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT}
+
+// forwardSignals forwards the signals received on ch to the process group of the shell until ch is closed.
+func forwardSignals(ch chan os.Signal, process *os.Process) {
+	for sig := range ch {
+		if s, ok := sig.(syscall.Signal); ok {
+			// The shell is started as a session leader, so its pid is also its process group id
+			_ = syscall.Kill(-process.Pid, s)
+		}
+	}
+}
+
+// copyInput copies stdin to dst until done is closed.
+// It waits for stdin to become readable with a timeout, so it never blocks in a read after the shell exited.
+func copyInput(dst io.Writer, done <-chan struct{}) {
+	fd := int(os.Stdin.Fd())
+	buf := make([]byte, 1024)
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		fds := &unix.FdSet{}
+		fds.Set(fd)
+		timeout := unix.NsecToTimeval(int64(100 * time.Millisecond))
+
+		n, err := unix.Select(fd+1, fds, nil, nil, &timeout)
+
+		if err == unix.EINTR {
+			continue
+		}
+
+		if err != nil {
+			return
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		n, err = os.Stdin.Read(buf)
+
+		if err != nil || n == 0 {
+			return
+		}
+
+		if _, err := dst.Write(buf[:n]); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,65 @@
+//go:build windows
+
+package crosspty
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/aymanbagabas/go-pty"
+	"golang.org/x/sys/windows"
+)
+
+// releaseTerminal is a no-op on Windows, ConPTY closes its pipes when the pseudo console is closed.
+func releaseTerminal(p pty.Pty) {}
+
+// setProcessGroup is a no-op on Windows, forwardSignals terminates the shell itself.
+func setProcessGroup(c *exec.Cmd) {}
+
+// terminationSignals are the signals which terminate the shell.
+var terminationSignals = []os.Signal{syscall.SIGTERM}
+
+// forwardSignals terminates the shell when a signal is received on ch, until ch is closed.
+func forwardSignals(ch chan os.Signal, process *os.Process) {
+	for range ch {
+		// Windows has no signals to forward, terminate the shell instead
+		_ = process.Kill()
+	}
+}
+
+// copyInput copies stdin to dst until done is closed.
+// It waits for the console input to be signaled with a timeout, so it never blocks in a read after the shell exited.
+func copyInput(dst io.Writer, done <-chan struct{}) {
+	handle := windows.Handle(os.Stdin.Fd())
+	buf := make([]byte, 1024)
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		event, err := windows.WaitForSingleObject(handle, 100)
+
+		if err != nil {
+			return
+		}
+
+		if event != windows.WAIT_OBJECT_0 {
+			continue
+		}
+
+		n, err := os.Stdin.Read(buf)
+
+		if err != nil || n == 0 {
+			return
+		}
+
+		if _, err := dst.Write(buf[:n]); err != nil {
+			return
+		}
+	}
+}
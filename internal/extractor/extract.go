@@ -9,7 +9,7 @@ import (
 )
 
 // Extract extracts the contents of a compressed file to a specified directory.
-// It supports files with the ".7z" and ".tar.xz" extensions.
+// It supports files with the ".7z", ".tar.xz", ".tar.gz" and ".tgz" extensions.
 //
 // Parameters:
 //   - fileName: The path to the compressed file to be extracted.
@@ -24,6 +24,8 @@ func Extract(fileName string, destFolder string) error {
 
 	if strings.HasSuffix(name, ".tar.xz") {
 		return extractTarXz(fileName, destFolder)
+	} else if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") {
+		return extractTarGz(fileName, destFolder)
 	} else if strings.HasSuffix(name, ".7z") {
 		return extract7Z(fileName, destFolder)
 	} else {
@@ -0,0 +1,59 @@
+package extractor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// extractTarGz extracts a .tgz or .tar.gz archive into the specified destination folder.
+func extractTarGz(tarGzFilePath, destFolder string) error {
+	file, err := os.Open(tarGzFilePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file %s", tarGzFilePath)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gzip reader")
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		header, err := tarReader.Next()
+
+		if err == io.EOF {
+			break // End of archive.
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "failed to read tar header")
+		}
+
+		// Archives packed by npm have no entries for directories
+		if header.Typeflag != tar.TypeDir {
+			destPath, err := getTarEntryPath(header, destFolder)
+
+			if err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if err := extractTarXzFile(tarReader, header, destFolder); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
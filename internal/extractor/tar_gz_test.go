@@ -0,0 +1,32 @@
+package extractor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractTarGzRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "package.tgz")
+
+	file, err := os.Create(archive)
+	assert.Nil(t, err)
+
+	gw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gw)
+
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "../../outside/dir/file", Mode: 0644, Typeflag: tar.TypeReg}))
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gw.Close())
+	assert.Nil(t, file.Close())
+
+	dest := filepath.Join(dir, "a", "b")
+
+	assert.NotNil(t, extractTarGz(archive, dest))
+	assert.NoDirExists(t, filepath.Join(dir, "outside"), "no directory should be created outside the destination")
+}
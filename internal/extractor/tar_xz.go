@@ -11,14 +11,25 @@ import (
 	"github.com/ulikunitz/xz"
 )
 
-// extractTarXzFile extracts a single file from the tar archive.
-func extractTarXzFile(reader *tar.Reader, header *tar.Header, destFolder string) error {
+// getTarEntryPath returns the destination path of the entry, or an error if it is outside the destination folder.
+func getTarEntryPath(header *tar.Header, destFolder string) (string, error) {
 	// Resolve the destination path.
 	destPath := filepath.Join(destFolder, header.Name)
 
 	// Ensure no file path traversal attacks by sanitizing the path.
 	if !strings.HasPrefix(destPath, filepath.Clean(destFolder)+string(os.PathSeparator)) {
-		return errors.Errorf("invalid file path: %s", header.Name)
+		return "", errors.Errorf("invalid file path: %s", header.Name)
+	}
+
+	return destPath, nil
+}
+
+// extractTarXzFile extracts a single file from the tar archive.
+func extractTarXzFile(reader *tar.Reader, header *tar.Header, destFolder string) error {
+	destPath, err := getTarEntryPath(header, destFolder)
+
+	if err != nil {
+		return err
 	}
 
 	switch header.Typeflag {
@@ -13,6 +13,8 @@ import (
 type CachedNode struct {
 	Version  string
 	FilePath string
+	Platform string // e.g. "linux", "darwin" or "win", empty if unknown
+	Arch     string // e.g. "x64" or "arm64", empty if unknown
 }
 
 // 按照版本号升序排序
@@ -77,10 +79,17 @@ func GetCachedVersions(nodaptDir string) ([]CachedNode, error) {
 
 			version := n[1]
 
-			list = append(list, CachedNode{
+			cache := CachedNode{
 				Version:  version,
 				FilePath: filepath.Join(nodeDir, fName),
-			})
+			}
+
+			if len(n) >= 4 {
+				cache.Platform = n[2]
+				cache.Arch = n[3]
+			}
+
+			list = append(list, cache)
 		}
 	}
 
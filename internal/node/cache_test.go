@@ -0,0 +1,45 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCachedVersions(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{
+		"node-v20.11.1-linux-x64",
+		"node-v18.20.0-darwin-arm64",
+		"node-v16.20.2",
+		"node-v22.1.0-win-x64",
+		"headers",
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "node", name), 0755))
+	}
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "node", "node-v14.0.0-linux-x64.tar.gz"), nil, 0644))
+
+	cached, err := GetCachedVersions(dir)
+
+	assert.NoError(t, err)
+
+	expected := []CachedNode{
+		{Version: "v16.20.2", FilePath: filepath.Join(dir, "node", "node-v16.20.2")},
+		{Version: "v18.20.0", FilePath: filepath.Join(dir, "node", "node-v18.20.0-darwin-arm64"), Platform: "darwin", Arch: "arm64"},
+		{Version: "v20.11.1", FilePath: filepath.Join(dir, "node", "node-v20.11.1-linux-x64"), Platform: "linux", Arch: "x64"},
+		{Version: "v22.1.0", FilePath: filepath.Join(dir, "node", "node-v22.1.0-win-x64"), Platform: "win", Arch: "x64"},
+	}
+
+	assert.Equal(t, expected, cached)
+}
+
+func TestGetCachedVersionsWithoutNodeDir(t *testing.T) {
+	cached, err := GetCachedVersions(t.TempDir())
+
+	assert.NoError(t, err)
+	assert.Empty(t, cached)
+}
@@ -0,0 +1,78 @@
+package node
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// parseChecksums parses the content of SHASUMS256.txt into a map of file name to SHA-256.
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) != 2 {
+			continue
+		}
+
+		checksums[fields[1]] = strings.ToLower(fields[0])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return checksums, nil
+}
+
+// GetChecksums returns the SHA-256 of the files of the version published by the mirror, keyed by file name.
+func GetChecksums(version string) (map[string]string, error) {
+	url := fmt.Sprintf("%sv%s/SHASUMS256.txt", NODE_MIRROR, strings.TrimPrefix(version, "v"))
+
+	util.Debug("checksumsURL: %s\n", url)
+
+	resp, err := http.Get(url)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, errors.Errorf("download file '%s' with status code %d", url, resp.StatusCode)
+	}
+
+	return parseChecksums(resp.Body)
+}
+
+// fileSHA256 returns the hex encoded SHA-256 of the file.
+func fileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -12,7 +12,46 @@ import (
 	"github.com/pkg/errors"
 )
 
+// Download installs the version into the dir and returns the installation directory.
 func Download(version string, dir string) (string, error) {
+	return download(version, dir, nil)
+}
+
+// DownloadVerified is like Download, but fails if the SHA-256 of the archive is not the one of the artifact.
+// An installed version is reinstalled unless it was installed from an archive with the same SHA-256.
+func DownloadVerified(version string, dir string, artifact *LockArtifact) (string, error) {
+	return download(version, dir, artifact)
+}
+
+// getChecksumFilePath returns the file recording the SHA-256 of the archive the version in nodeEnvPath was installed from.
+// It is recorded outside the installation, so the Node.js directory stays untouched.
+func getChecksumFilePath(dir string, nodeEnvPath string) string {
+	return filepath.Join(dir, "checksums", filepath.Base(nodeEnvPath))
+}
+
+// getInstalledChecksum returns the SHA-256 of the archive the version in nodeEnvPath was installed from, or an empty string if it is unknown.
+func getInstalledChecksum(dir string, nodeEnvPath string) string {
+	content, err := os.ReadFile(getChecksumFilePath(dir, nodeEnvPath))
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(content))
+}
+
+// setInstalledChecksum records the SHA-256 of the archive the version in nodeEnvPath was installed from.
+func setInstalledChecksum(dir string, nodeEnvPath string, checksum string) error {
+	filePath := getChecksumFilePath(dir, nodeEnvPath)
+
+	if err := util.EnsureDir(filepath.Dir(filePath)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.WriteFile(filePath, []byte(checksum), 0644))
+}
+
+func download(version string, dir string, verified *LockArtifact) (string, error) {
 	// Remove the 'v' prefix from the version string
 	version = strings.TrimPrefix(version, "v")
 
@@ -24,14 +63,23 @@ func Download(version string, dir string) (string, error) {
 
 	extractFolder := filepath.Join(dir, "node", artifact.FileName)
 
-	// Skip download if the folder already exists and contains files
+	installed := false
+
+	// Skip download if the folder already exists and contains files, and was installed from the verified archive if any
 	if _, err := os.Stat(extractFolder); err == nil {
 		if files, err := os.ReadDir(extractFolder); err == nil && len(files) > 0 {
-			return extractFolder, nil
+			if verified == nil || strings.EqualFold(getInstalledChecksum(dir, extractFolder), verified.SHA256) {
+				return extractFolder, nil
+			}
+
+			installed = true
+
+			util.Debug("%s is not installed from an archive with sha256 %s, reinstall it\n", extractFolder, verified.SHA256)
 		}
 	}
 
 	url := fmt.Sprintf("%sv%s/%s", NODE_MIRROR, version, artifact.FullName)
+
 	util.Debug("downloadURL: %s\n", url)
 
 	destFile := filepath.Join(dir, "download", artifact.FullName)
@@ -41,12 +89,38 @@ func Download(version string, dir string) (string, error) {
 		return "", errors.WithStack(err)
 	}
 
+	actual, err := fileSHA256(destFile)
+
+	if err != nil {
+		return "", err
+	}
+
+	if verified != nil {
+		if !strings.EqualFold(actual, verified.SHA256) {
+			_ = os.Remove(destFile)
+			return "", errors.Errorf("checksum mismatch of %s, expected sha256 %s but got %s", artifact.FullName, verified.SHA256, actual)
+		}
+
+		util.Debug("Verified sha256 of %s: %s\n", artifact.FullName, actual)
+	}
+
+	// Replace the installation which was not verified only once the archive is
+	if installed {
+		if err := os.RemoveAll(extractFolder); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
 	// Decompress the file into the node folder
 	if err := extractor.Extract(destFile, filepath.Dir(extractFolder)); err != nil {
 		// If extraction fails, the downloaded file remains for debugging
 		return "", errors.WithStack(err)
 	}
 
+	if err := setInstalledChecksum(dir, extractFolder, actual); err != nil {
+		util.Debug("Warning: failed to record the sha256 of %s: %v\n", extractFolder, err)
+	}
+
 	// Remove the downloaded file after successful extraction
 	if err := os.Remove(destFile); err != nil {
 		// Log warning but don't fail - extraction was successful
@@ -0,0 +1,101 @@
+//go:build !windows
+
+package node
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ulikunitz/xz"
+)
+
+// newNodeArchive returns a .tar.xz archive of a node installation with a node executable printing the content.
+func newNodeArchive(t *testing.T, fileName string, content string) []byte {
+	var buf bytes.Buffer
+
+	xw, err := xz.NewWriter(&buf)
+	assert.NoError(t, err)
+
+	tw := tar.NewWriter(xw)
+
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: fileName + "/bin/", Mode: 0755, Typeflag: tar.TypeDir}))
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: fileName + "/bin/node", Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+
+	_, err = tw.Write([]byte(content))
+	assert.NoError(t, err)
+
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, xw.Close())
+
+	return buf.Bytes()
+}
+
+func TestDownloadVerified(t *testing.T) {
+	artifact := GetRemoteArtifactTarget("18.20.0")
+
+	if artifact == nil {
+		t.Skip("unsupported platform")
+	}
+
+	archive := newNodeArchive(t, artifact.FileName, "#!/bin/sh\necho v18.20.0\n")
+	sum := sha256.Sum256(archive)
+	checksum := hex.EncodeToString(sum[:])
+
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	origMirror := NODE_MIRROR
+	defer func() { NODE_MIRROR = origMirror }()
+	NODE_MIRROR = server.URL + "/mirror/"
+
+	dir := t.TempDir()
+	locked := &LockArtifact{File: artifact.FullName, SHA256: strings.ToUpper(checksum)}
+
+	nodeEnvPath, err := DownloadVerified("v18.20.0", dir, locked)
+
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(nodeEnvPath, "bin", "node"))
+	assert.Equal(t, []string{"/mirror/v18.20.0/" + artifact.FullName}, requests, "the archive is downloaded from the mirror in use")
+	assert.Equal(t, checksum, getInstalledChecksum(dir, nodeEnvPath))
+
+	// The installation from the same archive is reused
+	_, err = DownloadVerified("v18.20.0", dir, locked)
+
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+
+	// An installation from another archive is verified again, and kept when the verification fails
+	_, err = DownloadVerified("v18.20.0", dir, &LockArtifact{SHA256: strings.Repeat("0", 64)})
+
+	assert.Error(t, err)
+	assert.FileExists(t, filepath.Join(nodeEnvPath, "bin", "node"))
+
+	// An installation with an unknown checksum is replaced by the verified one
+	assert.NoError(t, os.Remove(getChecksumFilePath(dir, nodeEnvPath)))
+
+	_, err = DownloadVerified("v18.20.0", dir, locked)
+
+	assert.NoError(t, err)
+	assert.Len(t, requests, 3)
+	assert.Equal(t, checksum, getInstalledChecksum(dir, nodeEnvPath))
+
+	// Without a checksum, any installation is used
+	_, err = Download("v18.20.0", dir)
+
+	assert.NoError(t, err)
+	assert.Len(t, requests, 3)
+}
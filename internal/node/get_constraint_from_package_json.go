@@ -8,10 +8,36 @@ import (
 
 type PackageJSONEngine struct {
 	Node *string `json:"node"`
+	Npm  *string `json:"npm"`
+	Pnpm *string `json:"pnpm"`
+	Yarn *string `json:"yarn"`
+}
+
+// Get returns the constraint of the engine, e.g. "npm", or nil if it is not specified.
+func (e *PackageJSONEngine) Get(name string) *string {
+	if e == nil {
+		return nil
+	}
+
+	switch name {
+	case "node":
+		return e.Node
+	case "npm":
+		return e.Npm
+	case "pnpm":
+		return e.Pnpm
+	case "yarn":
+		return e.Yarn
+	default:
+		return nil
+	}
 }
 
 type PackageJSON struct {
-	Engines *PackageJSONEngine `json:"engines"`
+	Engines        *PackageJSONEngine     `json:"engines"`
+	PackageManager *string                `json:"packageManager"`
+	Volta          *PackageJSONVolta      `json:"volta"`
+	DevEngines     *PackageJSONDevEngines `json:"devEngines"`
 }
 
 func readPackageJSON(path string) (PackageJSON, error) {
@@ -39,7 +65,8 @@ func readPackageJSON(path string) (PackageJSON, error) {
 }
 
 // GetConstraintFromPackageJSON retrieves the Node.js version specified in the
-// "engines" field of a package.json file located at the given path.
+// package.json file located at the given path. The fields are checked in the order of
+// GetNodeConstraintsFromPackageJSON: "devEngines.runtime", "volta.node" and "engines.node".
 //
 // Parameters:
 //   - packageJSONPath: A string representing the file path to the package.json.
@@ -49,15 +76,38 @@ func readPackageJSON(path string) (PackageJSON, error) {
 //     if the version is not specified.
 //   - An error if there was an issue reading the package.json file.
 func GetConstraintFromPackageJSON(packageJSONPath string) (*string, error) {
+	constraints, err := GetNodeConstraintsFromPackageJSON(packageJSONPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(constraints) == 0 {
+		return nil, nil
+	}
+
+	return &constraints[0].Constraint, nil
+}
+
+// GetPackageManagerFromPackageJSON returns the packageManager field of the package.json, e.g. "pnpm@9.1.0",
+// or nil if it is not specified.
+func GetPackageManagerFromPackageJSON(packageJSONPath string) (*string, error) {
 	packageJSON, err := readPackageJSON(packageJSONPath)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if packageJSON.Engines != nil && packageJSON.Engines.Node != nil {
-		return packageJSON.Engines.Node, nil
+	return packageJSON.PackageManager, nil
+}
+
+// GetEnginesFromPackageJSON returns the engines field of the package.json, or nil if it is not specified.
+func GetEnginesFromPackageJSON(packageJSONPath string) (*PackageJSONEngine, error) {
+	packageJSON, err := readPackageJSON(packageJSONPath)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, nil
+	return packageJSON.Engines, nil
 }
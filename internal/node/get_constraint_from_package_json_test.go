@@ -87,3 +87,49 @@ func TestGetVersionFromPackageJSON(t *testing.T) {
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestGetEnginesFromPackageJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		expected    map[string]*string
+	}{
+		{
+			name:        "All engines",
+			packageJSON: `{"engines": {"node": ">=20", "npm": ">=10", "pnpm": "^9", "yarn": "4.x", "vscode": "^1.80.0"}}`,
+			expected:    map[string]*string{"node": strPtr(">=20"), "npm": strPtr(">=10"), "pnpm": strPtr("^9"), "yarn": strPtr("4.x"), "vscode": nil},
+		},
+		{
+			name:        "Only npm",
+			packageJSON: `{"engines": {"npm": ">=10"}}`,
+			expected:    map[string]*string{"node": nil, "npm": strPtr(">=10"), "pnpm": nil},
+		},
+		{
+			name:        "No engines field",
+			packageJSON: `{"name": "example"}`,
+			expected:    map[string]*string{"node": nil, "npm": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := t.TempDir() + "/package.json"
+
+			if err := os.WriteFile(path, []byte(tt.packageJSON), 0644); err != nil {
+				t.Fatalf("Failed to write package.json: %v", err)
+			}
+
+			engines, err := GetEnginesFromPackageJSON(path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for name, expected := range tt.expected {
+				got := engines.Get(name)
+				if (got == nil) != (expected == nil) || (got != nil && *got != *expected) {
+					t.Errorf("engines.%s: expected %v, got %v", name, expected, got)
+				}
+			}
+		})
+	}
+}
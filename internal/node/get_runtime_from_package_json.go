@@ -0,0 +1,191 @@
+package node
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	OnFailError  = "error"  // Fail when the runtime can't be satisfied, the default of devEngines
+	OnFailWarn   = "warn"   // Print a warning and fall back to the next constraint
+	OnFailIgnore = "ignore" // Fall back to the next constraint silently
+)
+
+// PackageJSONVolta is the volta field of package.json, which pins the exact versions of the toolchain.
+type PackageJSONVolta struct {
+	Node    *string `json:"node"`
+	Npm     *string `json:"npm"`
+	Pnpm    *string `json:"pnpm"`
+	Yarn    *string `json:"yarn"`
+	Extends *string `json:"extends"` // The path of a JSON file whose volta field is inherited, relative to this file
+}
+
+// PackageJSONDevEngines is the devEngines field of package.json.
+type PackageJSONDevEngines struct {
+	Runtime json.RawMessage `json:"runtime"` // A PackageJSONDevEngine or an array of them
+}
+
+// PackageJSONDevEngine is an entry of devEngines, e.g. {"name": "node", "version": ">=20", "onFail": "error"}.
+type PackageJSONDevEngine struct {
+	Name    string  `json:"name"`
+	Version *string `json:"version"`
+	OnFail  string  `json:"onFail"`
+}
+
+// NodeConstraint is a node constraint of package.json and the field it comes from.
+type NodeConstraint struct {
+	Constraint string
+	Field      string // "devEngines.runtime", "volta.node" or "engines.node"
+	OnFail     string // What to do when the constraint can't be satisfied, one of OnFailError, OnFailWarn and OnFailIgnore
+}
+
+// GetNodeConstraintsFromPackageJSON returns the node constraints of the package.json in order of precedence:
+//
+//  1. "devEngines.runtime", the development runtime declared for npm, with its "onFail".
+//  2. "volta.node", the version pinned by Volta, including the one inherited with "extends".
+//  3. "engines.node", the versions supported by the package.
+//
+// Only "devEngines.runtime" may fall back to the next constraint, the others always fail with an error.
+func GetNodeConstraintsFromPackageJSON(packageJSONPath string) ([]NodeConstraint, error) {
+	packageJSON, err := readPackageJSON(packageJSONPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var constraints []NodeConstraint
+
+	runtime, err := getNodeRuntime(packageJSON.DevEngines)
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid devEngines.runtime")
+	}
+
+	if runtime != nil && runtime.Version != nil {
+		constraints = append(constraints, NodeConstraint{Constraint: *runtime.Version, Field: "devEngines.runtime", OnFail: runtime.OnFail})
+	}
+
+	volta, err := resolveVolta(packageJSONPath, packageJSON.Volta, map[string]bool{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if volta != nil && volta.Node != nil {
+		constraints = append(constraints, NodeConstraint{Constraint: *volta.Node, Field: "volta.node", OnFail: OnFailError})
+	}
+
+	if packageJSON.Engines != nil && packageJSON.Engines.Node != nil {
+		constraints = append(constraints, NodeConstraint{Constraint: *packageJSON.Engines.Node, Field: "engines.node", OnFail: OnFailError})
+	}
+
+	return constraints, nil
+}
+
+// GetVoltaFromPackageJSON returns the volta field of the package.json with "extends" resolved,
+// or nil if it is not specified.
+func GetVoltaFromPackageJSON(packageJSONPath string) (*PackageJSONVolta, error) {
+	packageJSON, err := readPackageJSON(packageJSONPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveVolta(packageJSONPath, packageJSON.Volta, map[string]bool{})
+}
+
+// resolveVolta merges the volta field of the file it extends into volta, the fields of volta win.
+func resolveVolta(path string, volta *PackageJSONVolta, visited map[string]bool) (*PackageJSONVolta, error) {
+	if volta == nil || volta.Extends == nil {
+		return volta, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	visited[absPath] = true
+
+	extendsPath := *volta.Extends
+
+	if !filepath.IsAbs(extendsPath) {
+		extendsPath = filepath.Join(filepath.Dir(absPath), extendsPath)
+	}
+
+	if visited[extendsPath] {
+		return nil, errors.Errorf("volta.extends of %s is circular", path)
+	}
+
+	extended, err := readPackageJSON(extendsPath)
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to read %s extended by volta of %s", extendsPath, path)
+	}
+
+	parent, err := resolveVolta(extendsPath, extended.Volta, visited)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := *volta
+	result.Extends = nil
+
+	if parent != nil {
+		for _, field := range []struct{ child, parent **string }{
+			{&result.Node, &parent.Node},
+			{&result.Npm, &parent.Npm},
+			{&result.Pnpm, &parent.Pnpm},
+			{&result.Yarn, &parent.Yarn},
+		} {
+			if *field.child == nil {
+				*field.child = *field.parent
+			}
+		}
+	}
+
+	return &result, nil
+}
+
+// getNodeRuntime returns the entry of devEngines.runtime whose name is node, or nil if there is none.
+// The runtime may be a single entry or an array of them, onFail defaults to error.
+func getNodeRuntime(devEngines *PackageJSONDevEngines) (*PackageJSONDevEngine, error) {
+	if devEngines == nil || len(devEngines.Runtime) == 0 || string(devEngines.Runtime) == "null" {
+		return nil, nil
+	}
+
+	var runtimes []PackageJSONDevEngine
+
+	if err := json.Unmarshal(devEngines.Runtime, &runtimes); err != nil {
+		var runtime PackageJSONDevEngine
+
+		if err := json.Unmarshal(devEngines.Runtime, &runtime); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		runtimes = []PackageJSONDevEngine{runtime}
+	}
+
+	for _, runtime := range runtimes {
+		if runtime.Name != "node" {
+			continue
+		}
+
+		switch runtime.OnFail {
+		case "", "download":
+			// nodapt always installs the missing runtime, so download fails like error when it can't be satisfied
+			runtime.OnFail = OnFailError
+		case OnFailError, OnFailWarn, OnFailIgnore:
+		default:
+			return nil, errors.Errorf("unsupported onFail %s, expected error, warn, ignore or download", runtime.OnFail)
+		}
+
+		return &runtime, nil
+	}
+
+	return nil, nil
+}
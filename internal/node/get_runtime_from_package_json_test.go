@@ -0,0 +1,126 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNodeConstraintsFromPackageJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		files       map[string]string
+		expected    []NodeConstraint
+		expectError bool
+	}{
+		{
+			name:        "Only engines",
+			packageJSON: `{"engines": {"node": ">=18"}}`,
+			expected:    []NodeConstraint{{Constraint: ">=18", Field: "engines.node", OnFail: OnFailError}},
+		},
+		{
+			name:        "Precedence",
+			packageJSON: `{"engines": {"node": ">=18"}, "volta": {"node": "20.11.1"}, "devEngines": {"runtime": {"name": "node", "version": "^20", "onFail": "warn"}}}`,
+			expected: []NodeConstraint{
+				{Constraint: "^20", Field: "devEngines.runtime", OnFail: OnFailWarn},
+				{Constraint: "20.11.1", Field: "volta.node", OnFail: OnFailError},
+				{Constraint: ">=18", Field: "engines.node", OnFail: OnFailError},
+			},
+		},
+		{
+			name:        "onFail defaults to error",
+			packageJSON: `{"devEngines": {"runtime": {"name": "node", "version": "^22"}}}`,
+			expected:    []NodeConstraint{{Constraint: "^22", Field: "devEngines.runtime", OnFail: OnFailError}},
+		},
+		{
+			name:        "onFail download fails like error",
+			packageJSON: `{"devEngines": {"runtime": {"name": "node", "version": "^22", "onFail": "download"}}}`,
+			expected:    []NodeConstraint{{Constraint: "^22", Field: "devEngines.runtime", OnFail: OnFailError}},
+		},
+		{
+			name:        "Runtime array",
+			packageJSON: `{"devEngines": {"runtime": [{"name": "bun", "version": "1.x"}, {"name": "node", "version": "^20", "onFail": "ignore"}]}}`,
+			expected:    []NodeConstraint{{Constraint: "^20", Field: "devEngines.runtime", OnFail: OnFailIgnore}},
+		},
+		{
+			name:        "Runtime of another name",
+			packageJSON: `{"devEngines": {"runtime": {"name": "deno"}}, "engines": {"node": ">=18"}}`,
+			expected:    []NodeConstraint{{Constraint: ">=18", Field: "engines.node", OnFail: OnFailError}},
+		},
+		{
+			name:        "Runtime without version",
+			packageJSON: `{"devEngines": {"runtime": {"name": "node"}}}`,
+			expected:    nil,
+		},
+		{
+			name:        "Unsupported onFail",
+			packageJSON: `{"devEngines": {"runtime": {"name": "node", "version": "^20", "onFail": "explode"}}}`,
+			expectError: true,
+		},
+		{
+			name:        "Volta extends",
+			packageJSON: `{"volta": {"npm": "10.2.4", "extends": "../root/package.json"}}`,
+			files:       map[string]string{"root/package.json": `{"volta": {"node": "20.11.1", "npm": "10.0.0"}}`},
+			expected:    []NodeConstraint{{Constraint: "20.11.1", Field: "volta.node", OnFail: OnFailError}},
+		},
+		{
+			name:        "Volta overrides the extended node",
+			packageJSON: `{"volta": {"node": "18.19.0", "extends": "../root/package.json"}}`,
+			files:       map[string]string{"root/package.json": `{"volta": {"node": "20.11.1"}}`},
+			expected:    []NodeConstraint{{Constraint: "18.19.0", Field: "volta.node", OnFail: OnFailError}},
+		},
+		{
+			name:        "Circular volta extends",
+			packageJSON: `{"volta": {"extends": "../root/package.json"}}`,
+			files:       map[string]string{"root/package.json": `{"volta": {"extends": "../pkg/package.json"}}`},
+			expectError: true,
+		},
+		{
+			name:        "Missing volta extends",
+			packageJSON: `{"volta": {"extends": "../root/package.json"}}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			files := map[string]string{"pkg/package.json": tt.packageJSON}
+			for name, content := range tt.files {
+				files[name] = content
+			}
+
+			for name, content := range files {
+				path := filepath.Join(dir, name)
+				assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+			}
+
+			constraints, err := GetNodeConstraintsFromPackageJSON(filepath.Join(dir, "pkg", "package.json"))
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, constraints)
+		})
+	}
+}
+
+func TestGetVoltaFromPackageJSON(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "volta.json"), []byte(`{"volta": {"node": "20.11.1", "yarn": "4.1.0"}}`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"volta": {"npm": "10.2.4", "extends": "./volta.json"}}`), 0644))
+
+	volta, err := GetVoltaFromPackageJSON(filepath.Join(dir, "package.json"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, &PackageJSONVolta{Node: strPtr("20.11.1"), Npm: strPtr("10.2.4"), Yarn: strPtr("4.1.0")}, volta)
+}
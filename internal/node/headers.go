@@ -0,0 +1,113 @@
+package node
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/axetroy/nodapt/internal/downloader"
+	"github.com/axetroy/nodapt/internal/extractor"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// getHeadersDir returns the directory of the headers of the version, usable as the nodedir of node-gyp.
+func getHeadersDir(version string, dir string) string {
+	return filepath.Join(dir, "headers", fmt.Sprintf("node-v%s", strings.TrimPrefix(version, "v")))
+}
+
+// GetHeaders returns the directory of the headers of the version downloaded by DownloadHeaders,
+// or an empty string if they are not downloaded.
+func GetHeaders(version string, dir string) string {
+	headersDir := getHeadersDir(version, dir)
+
+	if files, err := os.ReadDir(headersDir); err == nil && len(files) > 0 {
+		return headersDir
+	}
+
+	return ""
+}
+
+// DownloadHeaders downloads the headers of the version from the mirror, so native addons can be built offline.
+// It returns the directory of the headers, which is skipped if it already exists.
+// They are extracted into a temporary directory which is renamed into place, so GetHeaders never returns a partial one.
+func DownloadHeaders(version string, dir string) (string, error) {
+	version = strings.TrimPrefix(version, "v")
+
+	if headersDir := GetHeaders(version, dir); headersDir != "" {
+		return headersDir, nil
+	}
+
+	headersDir := getHeadersDir(version, dir)
+
+	if err := util.EnsureDir(filepath.Dir(headersDir)); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	tempDir, err := os.MkdirTemp(filepath.Dir(headersDir), "."+filepath.Base(headersDir)+"-")
+
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			util.Debug("Warning: failed to remove temporary directory %s: %v\n", tempDir, err)
+		}
+	}()
+
+	fileName := fmt.Sprintf("node-v%s-headers.tar.xz", version)
+
+	url := fmt.Sprintf("%sv%s/%s", NODE_MIRROR, version, fileName)
+	util.Debug("headersURL: %s\n", url)
+
+	destFile := filepath.Join(tempDir, fileName)
+
+	if err := downloader.DownloadFile(url, destFile); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// The archive contains the node-v<version> directory
+	if err := extractor.Extract(destFile, tempDir); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	extractedDir := filepath.Join(tempDir, filepath.Base(headersDir))
+
+	// node-gyp links addons against node.lib on Windows, which is published separately
+	if runtime.GOOS == "windows" {
+		arch := "x64"
+
+		if runtime.GOARCH == "arm64" {
+			arch = "arm64"
+		}
+
+		url := fmt.Sprintf("%sv%s/win-%s/node.lib", NODE_MIRROR, version, arch)
+		util.Debug("nodeLibURL: %s\n", url)
+
+		if err := downloader.DownloadFile(url, filepath.Join(extractedDir, "Release", "node.lib")); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	// Another run may have downloaded them in the meantime
+	if GetHeaders(version, dir) != "" {
+		return headersDir, nil
+	}
+
+	if err := os.RemoveAll(headersDir); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := os.Rename(extractedDir, headersDir); err != nil {
+		if GetHeaders(version, dir) != "" {
+			return headersDir, nil
+		}
+
+		return "", errors.WithStack(err)
+	}
+
+	return headersDir, nil
+}
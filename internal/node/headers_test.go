@@ -0,0 +1,99 @@
+package node
+
+import (
+	"archive/tar"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ulikunitz/xz"
+)
+
+func TestGetHeaders(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.Equal(t, "", GetHeaders("v20.11.1", dir))
+
+	headersDir := filepath.Join(dir, "headers", "node-v20.11.1")
+	assert.Nil(t, os.MkdirAll(filepath.Join(headersDir, "include", "node"), 0755))
+
+	assert.Equal(t, headersDir, GetHeaders("v20.11.1", dir))
+	assert.Equal(t, headersDir, GetHeaders("20.11.1", dir))
+
+	// The headers are removed with the version
+	nodeEnvPath := filepath.Join(dir, "node", "node-v20.11.1-linux-x64")
+	assert.Nil(t, os.MkdirAll(nodeEnvPath, 0755))
+	assert.Nil(t, RemoveCached(dir, CachedNode{Version: "v20.11.1", FilePath: nodeEnvPath}))
+
+	assert.Equal(t, "", GetHeaders("v20.11.1", dir))
+}
+
+// newHeadersArchive returns a .tar.xz archive of the headers of the version with a single header file.
+func newHeadersArchive(t *testing.T, version string) []byte {
+	var buf bytes.Buffer
+
+	xw, err := xz.NewWriter(&buf)
+	assert.NoError(t, err)
+
+	tw := tar.NewWriter(xw)
+	content := "#define NODE_VERSION \"" + version + "\"\n"
+
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "node-" + version + "/include/node/", Mode: 0755, Typeflag: tar.TypeDir}))
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "node-" + version + "/include/node/node_version.h", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+
+	_, err = tw.Write([]byte(content))
+	assert.NoError(t, err)
+
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, xw.Close())
+
+	return buf.Bytes()
+}
+
+func TestDownloadHeaders(t *testing.T) {
+	archive := newHeadersArchive(t, "v20.11.1")
+	corrupted := true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".tar.xz") && corrupted {
+			// The archive is cut off, so it fails to be extracted after a part of it is
+			_, _ = w.Write(archive[:len(archive)/2])
+			return
+		}
+
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	origMirror := NODE_MIRROR
+	defer func() { NODE_MIRROR = origMirror }()
+	NODE_MIRROR = server.URL + "/"
+
+	dir := t.TempDir()
+
+	_, err := DownloadHeaders("v20.11.1", dir)
+
+	assert.Error(t, err)
+	assert.Equal(t, "", GetHeaders("v20.11.1", dir), "a partial extraction is never used")
+
+	entries, err := os.ReadDir(filepath.Join(dir, "headers"))
+	assert.NoError(t, err)
+	assert.Empty(t, entries, "the temporary directory is removed")
+
+	corrupted = false
+
+	headersDir, err := DownloadHeaders("v20.11.1", dir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "headers", "node-v20.11.1"), headersDir)
+	assert.FileExists(t, filepath.Join(headersDir, "include", "node", "node_version.h"))
+
+	entries, err = os.ReadDir(filepath.Join(dir, "headers"))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,115 @@
+package node
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LockFileName is the name of the lockfile, it is placed next to package.json.
+const LockFileName = "nodapt.lock"
+
+// lockPlatforms are the platforms recorded in the lockfile, as GOOS and GOARCH.
+var lockPlatforms = [][2]string{
+	{"darwin", "amd64"},
+	{"darwin", "arm64"},
+	{"linux", "amd64"},
+	{"linux", "arm64"},
+	{"windows", "amd64"},
+	{"windows", "arm64"},
+}
+
+// LockArtifact is the archive of a platform, it is downloaded from the mirror in use,
+// so the lockfile is the same whatever mirror the developers use.
+type LockArtifact struct {
+	File   string `json:"file"`
+	SHA256 string `json:"sha256"`
+}
+
+// Lock is the node version resolved for the constraint of a project.
+type Lock struct {
+	Constraint string                  `json:"constraint"`
+	Version    string                  `json:"version"`
+	Artifacts  map[string]LockArtifact `json:"artifacts"` // Keyed by "<GOOS>-<GOARCH>"
+}
+
+func lockPlatform(goos string, goarch string) string {
+	return goos + "-" + goarch
+}
+
+// Artifact returns the artifact of the current platform, or nil if it is not recorded.
+func (l *Lock) Artifact() *LockArtifact {
+	if artifact, ok := l.Artifacts[lockPlatform(runtime.GOOS, runtime.GOARCH)]; ok {
+		return &artifact
+	}
+
+	return nil
+}
+
+// NewLock returns the lock of the version, with the checksums of every platform published by the mirror.
+func NewLock(constraint string, version string, checksums map[string]string) *Lock {
+	version = "v" + strings.TrimPrefix(version, "v")
+
+	lock := &Lock{
+		Constraint: constraint,
+		Version:    version,
+		Artifacts:  map[string]LockArtifact{},
+	}
+
+	for _, platform := range lockPlatforms {
+		artifact := GetRemoteArtifactTargetFor(strings.TrimPrefix(version, "v"), platform[0], platform[1])
+
+		if artifact == nil {
+			continue
+		}
+
+		// Not every platform is published for every version
+		checksum, ok := checksums[artifact.FullName]
+
+		if !ok {
+			continue
+		}
+
+		lock.Artifacts[lockPlatform(platform[0], platform[1])] = LockArtifact{
+			File:   artifact.FullName,
+			SHA256: checksum,
+		}
+	}
+
+	return lock
+}
+
+// ReadLock reads the lockfile, it returns nil if the file does not exist.
+func ReadLock(lockFilePath string) (*Lock, error) {
+	content, err := os.ReadFile(lockFilePath)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	var lock Lock
+
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, errors.WithMessagef(err, "failed to parse %s", lockFilePath)
+	}
+
+	return &lock, nil
+}
+
+// WriteLock writes the lockfile.
+func WriteLock(lockFilePath string, lock *Lock) error {
+	content, err := json.MarshalIndent(lock, "", "  ")
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.WriteFile(lockFilePath, append(content, '\n'), 0644))
+}
@@ -0,0 +1,50 @@
+package node
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLock(t *testing.T) {
+	checksums, err := parseChecksums(strings.NewReader(`
+3725aa4736c84d14a2963a09c971c6037e9cb25b3ce51ffc8ba8c9ff8e4c652b  node-v18.1.0-linux-x64.tar.xz
+ABCDEF  node-v18.1.0-darwin-arm64.tar.xz
+123456  node-v18.1.0-win-x64.7z
+789abc  node-v18.1.0-win-x64.zip
+`))
+	assert.Nil(t, err)
+
+	lock := NewLock("^18", "18.1.0", checksums)
+
+	assert.Equal(t, "^18", lock.Constraint)
+	assert.Equal(t, "v18.1.0", lock.Version)
+	assert.Equal(t, map[string]LockArtifact{
+		"linux-amd64": {
+			File:   "node-v18.1.0-linux-x64.tar.xz",
+			SHA256: "3725aa4736c84d14a2963a09c971c6037e9cb25b3ce51ffc8ba8c9ff8e4c652b",
+		},
+		"darwin-arm64": {
+			File:   "node-v18.1.0-darwin-arm64.tar.xz",
+			SHA256: "abcdef",
+		},
+		"windows-amd64": {
+			File:   "node-v18.1.0-win-x64.7z",
+			SHA256: "123456",
+		},
+	}, lock.Artifacts)
+
+	lockFilePath := filepath.Join(t.TempDir(), LockFileName)
+
+	missing, err := ReadLock(lockFilePath)
+	assert.Nil(t, err)
+	assert.Nil(t, missing)
+
+	assert.Nil(t, WriteLock(lockFilePath, lock))
+
+	read, err := ReadLock(lockFilePath)
+	assert.Nil(t, err)
+	assert.Equal(t, lock, read)
+}
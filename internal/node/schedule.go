@@ -0,0 +1,156 @@
+package node
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// The release schedule of Node.js, updated with each release of nodapt and by 'nodapt audit --update-schedule'
+//
+//go:embed schedule.json
+var embeddedSchedule []byte
+
+var NODE_SCHEDULE_URL = util.GetEnvsWithFallback("https://raw.githubusercontent.com/nodejs/Release/main/schedule.json", "NODE_SCHEDULE_URL")
+
+// ReleaseLine is a release line of Node.js in the release schedule, the dates are formatted as "2024-10-22".
+type ReleaseLine struct {
+	Start       string `json:"start"`
+	LTS         string `json:"lts,omitempty"`
+	Maintenance string `json:"maintenance,omitempty"`
+	End         string `json:"end"`
+	Codename    string `json:"codename,omitempty"`
+}
+
+// Schedule is the release schedule of Node.js keyed by release line, e.g. "v20" or "v0.12".
+type Schedule map[string]ReleaseLine
+
+// ReleaseLineOf returns the release line of the version, e.g. "v20" for v20.11.1 and "v0.12" for v0.12.18.
+func ReleaseLineOf(version string) (string, error) {
+	v, err := semver.NewVersion(version)
+
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if v.Major() == 0 {
+		return fmt.Sprintf("v0.%d", v.Minor()), nil
+	}
+
+	return fmt.Sprintf("v%d", v.Major()), nil
+}
+
+// Line returns the release line of the version, or nil if the schedule doesn't have it.
+func (s Schedule) Line(version string) *ReleaseLine {
+	name, err := ReleaseLineOf(version)
+
+	if err != nil {
+		return nil
+	}
+
+	if line, ok := s[name]; ok {
+		return &line
+	}
+
+	return nil
+}
+
+// IsEOL reports whether the release line reached its end-of-life at the time.
+func (l ReleaseLine) IsEOL(now time.Time) bool {
+	end, err := time.Parse(time.DateOnly, l.End)
+
+	if err != nil {
+		return false
+	}
+
+	return !now.Before(end)
+}
+
+// Status returns the status of the release line at the time: "current", "active lts", "maintenance" or "eol".
+func (l ReleaseLine) Status(now time.Time) string {
+	before := func(date string) bool {
+		t, err := time.Parse(time.DateOnly, date)
+		return err == nil && now.Before(t)
+	}
+
+	switch {
+	case l.IsEOL(now):
+		return "eol"
+	case l.Maintenance != "" && !before(l.Maintenance):
+		return "maintenance"
+	case l.LTS != "" && !before(l.LTS):
+		return "active lts"
+	default:
+		return "current"
+	}
+}
+
+func getScheduleFilePath(nodaptDir string) string {
+	return filepath.Join(nodaptDir, "schedule.json")
+}
+
+// LoadSchedule returns the release schedule downloaded by UpdateSchedule, or the embedded one if there is none.
+func LoadSchedule(nodaptDir string) (Schedule, error) {
+	content, err := os.ReadFile(getScheduleFilePath(nodaptDir))
+
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, errors.WithStack(err)
+		}
+
+		content = embeddedSchedule
+	}
+
+	var schedule Schedule
+
+	if err := json.Unmarshal(content, &schedule); err != nil {
+		return nil, errors.WithMessage(err, "failed to parse the release schedule")
+	}
+
+	return schedule, nil
+}
+
+// UpdateSchedule downloads the latest release schedule into the nodapt directory.
+func UpdateSchedule(nodaptDir string) error {
+	util.Debug("scheduleURL: %s\n", NODE_SCHEDULE_URL)
+
+	resp, err := http.Get(NODE_SCHEDULE_URL)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("download file '%s' with status code %d", NODE_SCHEDULE_URL, resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// Don't replace a working schedule with a broken one
+	var schedule Schedule
+
+	if err := json.Unmarshal(content, &schedule); err != nil {
+		return errors.WithMessage(err, "failed to parse the release schedule")
+	}
+
+	if err := util.EnsureDir(nodaptDir); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.WriteFile(getScheduleFilePath(nodaptDir), content, 0644))
+}
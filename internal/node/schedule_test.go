@@ -0,0 +1,59 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule(t *testing.T) {
+	schedule, err := LoadSchedule(t.TempDir())
+	assert.Nil(t, err, "the embedded schedule is valid")
+
+	line := schedule.Line("v20.11.1")
+	assert.NotNil(t, line)
+	assert.Equal(t, "Iron", line.Codename)
+	assert.Nil(t, schedule.Line("v1.0.0"))
+	assert.NotNil(t, schedule.Line("v0.12.18"))
+
+	date := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+
+	tests := []struct {
+		now    string
+		status string
+	}{
+		{"2023-05-01", "current"},
+		{"2023-10-24", "active lts"},
+		{"2025-01-01", "maintenance"},
+		{"2026-04-29", "maintenance"},
+		{"2026-04-30", "eol"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.status, line.Status(date(tt.now)), tt.now)
+		assert.Equal(t, tt.status == "eol", line.IsEOL(date(tt.now)), tt.now)
+	}
+}
+
+func TestNewerSecurityRelease(t *testing.T) {
+	versions := Versions{
+		{Version: "v22.1.0", Security: true},
+		{Version: "v20.12.0"},
+		{Version: "v20.11.1", Security: true},
+		{Version: "v20.11.0"},
+		{Version: "v20.10.0", Security: true},
+		{Version: "v0.12.18", Security: true},
+	}
+
+	assert.Equal(t, "v20.11.1", versions.NewerSecurityRelease("v20.10.0").Version)
+	assert.Equal(t, "v20.11.1", versions.NewerSecurityRelease("v20.11.0").Version)
+	assert.Nil(t, versions.NewerSecurityRelease("v20.11.1"))
+	assert.Nil(t, versions.NewerSecurityRelease("v20.12.0"))
+	assert.Nil(t, versions.NewerSecurityRelease("v21.0.0"))
+	assert.Equal(t, "v0.12.18", versions.NewerSecurityRelease("v0.12.0").Version)
+	assert.Nil(t, versions.NewerSecurityRelease("v0.10.0"))
+}
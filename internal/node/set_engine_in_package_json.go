@@ -0,0 +1,233 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonMember is a member of a JSON object, with the byte offsets of its value.
+type jsonMember struct {
+	Key        string
+	ValueStart int
+	ValueEnd   int
+}
+
+// jsonObject is a JSON object with the byte offsets of its braces.
+type jsonObject struct {
+	Open    int
+	Close   int
+	Members []jsonMember
+}
+
+func (o jsonObject) get(key string) *jsonMember {
+	for i := range o.Members {
+		if o.Members[i].Key == key {
+			return &o.Members[i]
+		}
+	}
+
+	return nil
+}
+
+// scanJSONObject returns the members of the JSON object starting at offset start of content.
+func scanJSONObject(content []byte, start int) (*jsonObject, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content[start:]))
+
+	if token, err := decoder.Token(); err != nil {
+		return nil, errors.WithStack(err)
+	} else if token != json.Delim('{') {
+		return nil, errors.New("expected a JSON object")
+	}
+
+	object := &jsonObject{Open: start + int(decoder.InputOffset()) - 1}
+
+	for {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if token == json.Delim('}') {
+			object.Close = start + int(decoder.InputOffset()) - 1
+			return object, nil
+		}
+
+		key, ok := token.(string)
+
+		if !ok {
+			return nil, errors.Errorf("unexpected token %v", token)
+		}
+
+		valueStart := start + int(decoder.InputOffset())
+
+		for valueStart < len(content) && (content[valueStart] == ':' || isJSONSpace(content[valueStart])) {
+			valueStart++
+		}
+
+		if err := skipJSONValue(decoder); err != nil {
+			return nil, err
+		}
+
+		object.Members = append(object.Members, jsonMember{
+			Key:        key,
+			ValueStart: valueStart,
+			ValueEnd:   start + int(decoder.InputOffset()),
+		})
+	}
+}
+
+// skipJSONValue reads the next value from the decoder, including nested objects and arrays.
+func skipJSONValue(decoder *json.Decoder) error {
+	depth := 0
+
+	for {
+		token, err := decoder.Token()
+
+		if err != nil {
+			if err == io.EOF {
+				return errors.New("unexpected end of JSON")
+			}
+
+			return errors.WithStack(err)
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func marshalJSONString(value string) string {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false) // Keep constraints such as ">=18 <21" readable
+
+	_ = encoder.Encode(value)
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// detectJSONFormat returns the newline and the indentation of one level used by the JSON content.
+// Both are empty when the content is on a single line.
+func detectJSONFormat(content []byte, object *jsonObject) (newLine string, indent string) {
+	if len(object.Members) == 0 {
+		return "", ""
+	}
+
+	// The whitespace before the first key is the newline followed by the indentation
+	leading := content[object.Open+1 : object.Members[0].ValueStart]
+	end := bytes.IndexByte(leading, '"')
+
+	if end >= 0 {
+		leading = leading[:end]
+	}
+
+	i := bytes.LastIndexByte(leading, '\n')
+
+	if i < 0 {
+		return "", ""
+	}
+
+	newLine = "\n"
+
+	if i > 0 && leading[i-1] == '\r' {
+		newLine = "\r\n"
+	}
+
+	return newLine, string(leading[i+1:])
+}
+
+// insertJSONMember returns the content with the member appended to the object at the given depth.
+func insertJSONMember(content []byte, object *jsonObject, member string, newLine string, indent string, depth int) []byte {
+	result := make([]byte, 0, len(content)+len(member)+len(newLine)+len(indent)*depth)
+
+	if len(object.Members) == 0 {
+		// Replace the whitespace inside the empty object
+		result = append(result, content[:object.Open+1]...)
+		result = append(result, newLine+strings.Repeat(indent, depth)+member+newLine+strings.Repeat(indent, depth-1)...)
+		return append(result, content[object.Close:]...)
+	}
+
+	last := object.Members[len(object.Members)-1]
+
+	result = append(result, content[:last.ValueEnd]...)
+	result = append(result, ","+newLine+strings.Repeat(indent, depth)+member...)
+
+	return append(result, content[last.ValueEnd:]...)
+}
+
+// SetEngineInPackageJSON returns the package.json content with engines.<name> set to the value.
+// The content is edited in place instead of being marshaled again,
+// so the order of the keys, the indentation and the trailing newline are kept.
+func SetEngineInPackageJSON(content []byte, name string, value string) ([]byte, error) {
+	start := bytes.IndexByte(content, '{')
+
+	if start < 0 {
+		return nil, errors.New("invalid package.json, it is not an object")
+	}
+
+	root, err := scanJSONObject(content, start)
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid package.json")
+	}
+
+	newLine, indent := detectJSONFormat(content, root)
+
+	if newLine == "" && len(root.Members) == 0 {
+		// An empty package.json, use the format of npm
+		newLine, indent = "\n", "  "
+	}
+
+	separator := ": "
+
+	if newLine == "" {
+		separator = ":"
+	}
+
+	engine := marshalJSONString(name) + separator + marshalJSONString(value)
+	engines := root.get("engines")
+
+	if engines == nil {
+		member := marshalJSONString("engines") + separator + "{" + newLine + strings.Repeat(indent, 2) + engine + newLine + indent + "}"
+
+		return insertJSONMember(content, root, member, newLine, indent, 1), nil
+	}
+
+	if content[engines.ValueStart] != '{' {
+		return nil, errors.New("invalid package.json, engines is not an object")
+	}
+
+	enginesObject, err := scanJSONObject(content, engines.ValueStart)
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid package.json")
+	}
+
+	if existing := enginesObject.get(name); existing != nil {
+		result := make([]byte, 0, len(content)+len(value))
+		result = append(result, content[:existing.ValueStart]...)
+		result = append(result, marshalJSONString(value)...)
+
+		return append(result, content[existing.ValueEnd:]...), nil
+	}
+
+	return insertJSONMember(content, enginesObject, engine, newLine, indent, 2), nil
+}
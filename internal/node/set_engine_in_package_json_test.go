@@ -0,0 +1,71 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetEngineInPackageJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:        "Replace existing engine",
+			packageJSON: "{\n  \"name\": \"example\",\n  \"engines\": {\n    \"node\": \"14.x\",\n    \"npm\": \">=8\"\n  }\n}\n",
+			expected:    "{\n  \"name\": \"example\",\n  \"engines\": {\n    \"node\": \">=18 <21\",\n    \"npm\": \">=8\"\n  }\n}\n",
+		},
+		{
+			name:        "Add engine to existing engines",
+			packageJSON: "{\n    \"engines\": {\n        \"npm\": \">=8\"\n    },\n    \"name\": \"example\"\n}",
+			expected:    "{\n    \"engines\": {\n        \"npm\": \">=8\",\n        \"node\": \">=18 <21\"\n    },\n    \"name\": \"example\"\n}",
+		},
+		{
+			name:        "Add engine to empty engines",
+			packageJSON: "{\n\t\"engines\": {}\n}\n",
+			expected:    "{\n\t\"engines\": {\n\t\t\"node\": \">=18 <21\"\n\t}\n}\n",
+		},
+		{
+			name:        "Add engines after the last key",
+			packageJSON: "{\r\n  \"name\": \"example\",\r\n  \"scripts\": {\r\n    \"dev\": \"vite\"\r\n  }\r\n}\r\n",
+			expected:    "{\r\n  \"name\": \"example\",\r\n  \"scripts\": {\r\n    \"dev\": \"vite\"\r\n  },\r\n  \"engines\": {\r\n    \"node\": \">=18 <21\"\r\n  }\r\n}\r\n",
+		},
+		{
+			name:        "Single line",
+			packageJSON: `{"name":"example"}`,
+			expected:    `{"name":"example","engines":{"node":">=18 <21"}}`,
+		},
+		{
+			name:        "Empty object",
+			packageJSON: "{}\n",
+			expected:    "{\n  \"engines\": {\n    \"node\": \">=18 <21\"\n  }\n}\n",
+		},
+		{
+			name:        "Engines is not an object",
+			packageJSON: `{"engines": "node"}`,
+			expectError: true,
+		},
+		{
+			name:        "Invalid JSON",
+			packageJSON: `{"name": }`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := SetEngineInPackageJSON([]byte(tt.packageJSON), "node", ">=18 <21")
+
+			if tt.expectError {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, string(result))
+		})
+	}
+}
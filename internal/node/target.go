@@ -1,7 +1,79 @@
 package node
 
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/Masterminds/semver/v3"
+)
+
 type RemoteArtifactTarget struct {
 	FullName string
 	FileName string
 	Ext      string
 }
+
+// GetRemoteArtifactTarget returns the artifact of the version for the current platform,
+// or nil if the platform is not supported.
+func GetRemoteArtifactTarget(version string) *RemoteArtifactTarget {
+	return GetRemoteArtifactTargetFor(version, runtime.GOOS, runtime.GOARCH)
+}
+
+// GetRemoteArtifactTargetFor returns the artifact of the version for the platform,
+// or nil if the platform is not supported.
+func GetRemoteArtifactTargetFor(version string, goos string, goarch string) *RemoteArtifactTarget {
+	fileName := getNodeFileName(version, goos, goarch)
+
+	if fileName == nil {
+		return nil
+	}
+
+	ext := ".tar.xz"
+
+	if goos == "windows" {
+		ext = ".7z"
+	}
+
+	return &RemoteArtifactTarget{
+		FileName: *fileName,
+		FullName: fmt.Sprintf("%s%s", *fileName, ext),
+		Ext:      ext,
+	}
+}
+
+func getNodeFileName(version string, goos string, goarch string) *string {
+	var platform string
+
+	switch goos {
+	case "darwin":
+		platform = "darwin"
+	case "linux":
+		platform = "linux"
+	case "windows":
+		platform = "win"
+	default:
+		return nil
+	}
+
+	var arch string
+
+	switch goarch {
+	case "amd64":
+		arch = "x64"
+	case "arm64":
+		arch = "arm64"
+
+		// Node.js 16.0.0 and later versions have official support for Apple Silicon
+		// https://nodejs.org/en/blog/release/v16.0.0/
+		if goos == "darwin" {
+			if c, err := semver.NewConstraint("< 16.0.0"); err == nil && c.Check(semver.MustParse(version)) {
+				arch = "x64"
+			}
+		}
+	default:
+		return nil
+	}
+
+	str := fmt.Sprintf("node-v%s-%s-%s", version, platform, arch)
+	return &str
+}
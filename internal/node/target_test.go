@@ -0,0 +1,37 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRemoteArtifactTargetFor(t *testing.T) {
+	tests := []struct {
+		version  string
+		goos     string
+		goarch   string
+		expected string
+	}{
+		{"20.11.1", "linux", "amd64", "node-v20.11.1-linux-x64.tar.xz"},
+		{"20.11.1", "linux", "arm64", "node-v20.11.1-linux-arm64.tar.xz"},
+		{"20.11.1", "darwin", "arm64", "node-v20.11.1-darwin-arm64.tar.xz"},
+		{"14.21.3", "darwin", "arm64", "node-v14.21.3-darwin-x64.tar.xz"},
+		{"20.11.1", "windows", "amd64", "node-v20.11.1-win-x64.7z"},
+		{"20.11.1", "linux", "386", ""},
+		{"20.11.1", "freebsd", "amd64", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos+"-"+tt.goarch+"-"+tt.version, func(t *testing.T) {
+			target := GetRemoteArtifactTargetFor(tt.version, tt.goos, tt.goarch)
+
+			if tt.expected == "" {
+				assert.Nil(t, target)
+				return
+			}
+
+			assert.Equal(t, tt.expected, target.FullName)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// getUsageFilePath returns the file recording when the installed version in nodeEnvPath was last used.
+// Usage is recorded outside the installation, so the Node.js directory stays untouched.
+func getUsageFilePath(nodaptDir string, nodeEnvPath string) string {
+	return filepath.Join(nodaptDir, "usage", filepath.Base(nodeEnvPath))
+}
+
+// MarkUsed records that the installed version in nodeEnvPath has just been used by run or use.
+func MarkUsed(nodaptDir string, nodeEnvPath string) error {
+	filePath := getUsageFilePath(nodaptDir, nodeEnvPath)
+
+	if err := util.EnsureDir(filepath.Dir(filePath)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.WriteFile(filePath, []byte(time.Now().UTC().Format(time.RFC3339)), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// GetLastUsed returns when the installed version in nodeEnvPath was last used, or nil if it never was.
+func GetLastUsed(nodaptDir string, nodeEnvPath string) *time.Time {
+	content, err := os.ReadFile(getUsageFilePath(nodaptDir, nodeEnvPath))
+
+	if err != nil {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(content)))
+
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
+
+// RemoveCached removes the installed version, its usage and checksum records and its headers.
+func RemoveCached(nodaptDir string, cache CachedNode) error {
+	if err := os.RemoveAll(cache.FilePath); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := os.Remove(getUsageFilePath(nodaptDir, cache.FilePath)); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+
+	if err := os.Remove(getChecksumFilePath(nodaptDir, cache.FilePath)); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+
+	if err := os.RemoveAll(getHeadersDir(cache.Version, nodaptDir)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
@@ -2,17 +2,46 @@ package node
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/axetroy/nodapt/internal/util"
 	"github.com/axetroy/nodapt/internal/version_constraint"
 	"github.com/pkg/errors"
 )
 
-type Versions []struct {
-	Version string `json:"version"`
-	LTS     any    `json:"lts"`
+// Version is a release of Node.js in the index.json of the mirror.
+type Version struct {
+	Version  string   `json:"version"`
+	Date     string   `json:"date"` // The release date, e.g. "2024-02-14"
+	Files    []string `json:"files"`
+	Npm      string   `json:"npm"`
+	V8       string   `json:"v8"`
+	Uv       string   `json:"uv"`
+	Zlib     string   `json:"zlib"`
+	OpenSSL  string   `json:"openssl"`
+	Modules  string   `json:"modules"`
+	LTS      any      `json:"lts"` // false, or the codename of the LTS line, e.g. "Iron"
+	Security bool     `json:"security"`
+}
+
+type Versions []Version
+
+// IndexCacheFile is where the index.json is saved after every fetch, so it can be read without network. Empty disables it.
+var IndexCacheFile string
+
+// LTSName returns the codename of the LTS line of the release, or an empty string if it is not an LTS release.
+func (v Version) LTSName() string {
+	if name, ok := v.LTS.(string); ok {
+		return name
+	}
+
+	return ""
 }
 
 // GetAllVersions retrieves a list of all available Node.js versions from the official Node.js distribution index.
@@ -30,15 +59,103 @@ func GetAllVersions() (Versions, error) {
 
 	defer resp.Body.Close()
 
+	content, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get node versions")
+	}
+
 	var versions Versions
 
-	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+	if err := json.Unmarshal(content, &versions); err != nil {
 		return nil, errors.WithMessage(err, "failed to decode node versions")
 	}
 
+	if IndexCacheFile != "" {
+		if err := saveIndexCache(content); err != nil {
+			util.Debug("Warning: failed to save %s: %v\n", IndexCacheFile, err)
+		}
+	}
+
+	return versions, nil
+}
+
+// saveIndexCache saves the content of index.json into IndexCacheFile.
+func saveIndexCache(content []byte) error {
+	if err := util.EnsureDir(filepath.Dir(IndexCacheFile)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.WriteFile(IndexCacheFile, content, 0644))
+}
+
+// GetCachedAllVersions returns the versions saved by the last GetAllVersions, or nil if there are none.
+func GetCachedAllVersions() (Versions, error) {
+	if IndexCacheFile == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(IndexCacheFile)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	var versions Versions
+
+	if err := json.Unmarshal(content, &versions); err != nil {
+		return nil, errors.WithMessagef(err, "failed to decode %s", IndexCacheFile)
+	}
+
 	return versions, nil
 }
 
+// NewerSecurityRelease returns the newest security release of the release line of the version
+// which is newer than the version, or nil if there is none.
+func (versions Versions) NewerSecurityRelease(version string) *Version {
+	current, err := semver.NewVersion(version)
+
+	if err != nil {
+		return nil
+	}
+
+	line, err := ReleaseLineOf(version)
+
+	if err != nil {
+		return nil
+	}
+
+	var newest *Version
+	var newestVersion *semver.Version
+
+	for i := range versions {
+		if !versions[i].Security {
+			continue
+		}
+
+		v, err := semver.NewVersion(versions[i].Version)
+
+		if err != nil || !v.GreaterThan(current) {
+			continue
+		}
+
+		if l, err := ReleaseLineOf(versions[i].Version); err != nil || l != line {
+			continue
+		}
+
+		if newestVersion == nil || v.GreaterThan(newestVersion) {
+			newest = &versions[i]
+			newestVersion = v
+		}
+	}
+
+	return newest
+}
+
 // GetMatchVersion returns the first version that matches the provided semantic version constraint.
 // It retrieves all available node versions and checks each one against the given constraint.
 //
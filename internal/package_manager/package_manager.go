@@ -0,0 +1,307 @@
+package package_manager
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/axetroy/nodapt/internal/downloader"
+	"github.com/axetroy/nodapt/internal/extractor"
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+var NPM_REGISTRY = getRegistry("https://registry.npmjs.org/")
+
+func getRegistry(defaultRegistry string) string {
+	registry := defaultRegistry
+
+	if util.IsSimplifiedChinese() {
+		registry = "https://registry.npmmirror.com/"
+	}
+
+	registry = util.GetEnvsWithFallback(registry, "NODAPT_NPM_REGISTRY", "NPM_CONFIG_REGISTRY", "npm_config_registry")
+
+	if !strings.HasSuffix(registry, "/") {
+		registry += "/"
+	}
+
+	return registry
+}
+
+var hashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha224": sha256.New224,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// integrityFileName is the file of an install which records the hash its tarball was verified with.
+const integrityFileName = ".integrity"
+
+// PackageManager is the packageManager field of package.json, e.g. "pnpm@9.1.0+sha512.<hex>".
+type PackageManager struct {
+	Name          string // npm, pnpm or yarn
+	Version       string
+	HashAlgorithm string // Empty if the field has no hash
+	Hash          string // The hex encoded hash of the tarball
+}
+
+func (p PackageManager) String() string {
+	return p.Name + "@" + p.Version
+}
+
+// Parse parses the packageManager field of package.json.
+func Parse(field string) (*PackageManager, error) {
+	spec, hashPart, hasHash := strings.Cut(field, "+")
+
+	name, version, ok := strings.Cut(spec, "@")
+
+	if !ok || version == "" {
+		return nil, errors.Errorf("invalid packageManager %s, expected <name>@<version>", field)
+	}
+
+	switch name {
+	case "npm", "pnpm", "yarn":
+	default:
+		return nil, errors.Errorf("unsupported package manager %s, expected npm, pnpm or yarn", name)
+	}
+
+	if _, err := semver.StrictNewVersion(version); err != nil {
+		return nil, errors.Errorf("invalid packageManager %s, the version must be exact", field)
+	}
+
+	pm := &PackageManager{Name: name, Version: version}
+
+	if hasHash {
+		algorithm, value, ok := strings.Cut(hashPart, ".")
+
+		if _, supported := hashes[algorithm]; !ok || !supported || value == "" {
+			return nil, errors.Errorf("invalid hash of packageManager %s, expected +<sha1|sha224|sha256|sha512>.<hex>", field)
+		}
+
+		pm.HashAlgorithm = algorithm
+		pm.Hash = strings.ToLower(value)
+	}
+
+	return pm, nil
+}
+
+// packageName returns the name of the npm package which distributes the package manager.
+func (p PackageManager) packageName() string {
+	// Yarn 2 and later are published as a single bundle
+	if p.Name == "yarn" {
+		if v, err := semver.NewVersion(p.Version); err == nil && v.Major() >= 2 {
+			return "@yarnpkg/cli-dist"
+		}
+	}
+
+	return p.Name
+}
+
+// TarballURL returns the URL of the tarball of the package manager in the registry.
+func (p PackageManager) TarballURL(registry string) string {
+	name := p.packageName()
+
+	return fmt.Sprintf("%s%s/-/%s-%s.tgz", registry, name, path.Base(name), p.Version)
+}
+
+// integrity returns the hash of the field as <algorithm>.<hex>, as recorded next to a verified install.
+func (p PackageManager) integrity() string {
+	return p.HashAlgorithm + "." + p.Hash
+}
+
+func (p PackageManager) verify(filePath string) error {
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer file.Close()
+
+	h := hashes[p.HashAlgorithm]()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != p.Hash {
+		return errors.Errorf("checksum mismatch of %s, expected %s %s but got %s", p, p.HashAlgorithm, p.Hash, actual)
+	}
+
+	return nil
+}
+
+// writeShims creates an executable for every bin of the package in binDir, which runs it with the node in PATH.
+// The package is read from packageDir, and the executables run it from installedPackageDir where it is moved to.
+func writeShims(packageDir string, installedPackageDir string, binDir string) error {
+	content, err := os.ReadFile(filepath.Join(packageDir, "package.json"))
+
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var packageJSON struct {
+		Name string          `json:"name"`
+		Bin  json.RawMessage `json:"bin"`
+	}
+
+	if err := json.Unmarshal(content, &packageJSON); err != nil {
+		return errors.WithStack(err)
+	}
+
+	bins := map[string]string{}
+
+	// The bin field is either a map of names to files, or the file of the bin named after the package
+	if err := json.Unmarshal(packageJSON.Bin, &bins); err != nil {
+		var file string
+
+		if err := json.Unmarshal(packageJSON.Bin, &file); err != nil {
+			return errors.Errorf("no bin found in %s", packageDir)
+		}
+
+		bins[path.Base(packageJSON.Name)] = file
+	}
+
+	if err := util.EnsureDir(binDir); err != nil {
+		return errors.WithStack(err)
+	}
+
+	names := make([]string, 0, len(bins))
+
+	for name := range bins {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		script := filepath.Join(installedPackageDir, filepath.FromSlash(bins[name]))
+
+		if runtime.GOOS == "windows" {
+			err = os.WriteFile(filepath.Join(binDir, name+".cmd"), []byte(fmt.Sprintf("@node \"%s\" %%*\r\n", script)), 0755)
+		} else {
+			err = os.WriteFile(filepath.Join(binDir, name), []byte(fmt.Sprintf("#!/bin/sh\nexec node \"%s\" \"$@\"\n", script)), 0755)
+		}
+
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// isInstalled reports whether the package manager is installed in installDir, from a tarball with the same hash if the field has one.
+func (p PackageManager) isInstalled(installDir string) bool {
+	if files, err := os.ReadDir(filepath.Join(installDir, "bin")); err != nil || len(files) == 0 {
+		return false
+	}
+
+	if p.Hash == "" {
+		return true
+	}
+
+	content, err := os.ReadFile(filepath.Join(installDir, integrityFileName))
+
+	return err == nil && strings.TrimSpace(string(content)) == p.integrity()
+}
+
+// Install downloads the package manager into the dir, verifying the hash of the field if any,
+// and returns the directory with its executables.
+// It is installed into a temporary directory which is renamed into place, so concurrent installs never see a partial one.
+func Install(p *PackageManager, dir string) (string, error) {
+	installDir := filepath.Join(dir, "package-managers", p.String())
+	binDir := filepath.Join(installDir, "bin")
+
+	// Skip download if it is already installed
+	if p.isInstalled(installDir) {
+		return binDir, nil
+	}
+
+	if _, err := os.Stat(installDir); err == nil {
+		util.Debug("%s is installed without the hash %s, reinstall it\n", p, p.integrity())
+	}
+
+	if err := util.EnsureDir(filepath.Dir(installDir)); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	tempDir, err := os.MkdirTemp(filepath.Dir(installDir), "."+p.String()+"-")
+
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			util.Debug("Warning: failed to remove temporary directory %s: %v\n", tempDir, err)
+		}
+	}()
+
+	url := p.TarballURL(NPM_REGISTRY)
+	util.Debug("packageManagerURL: %s\n", url)
+
+	destFile := filepath.Join(tempDir, path.Base(url))
+
+	if err := downloader.DownloadFile(url, destFile); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if p.Hash != "" {
+		if err := p.verify(destFile); err != nil {
+			return "", err
+		}
+	}
+
+	// The tarball contains the package directory
+	if err := extractor.Extract(destFile, tempDir); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := os.Remove(destFile); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := writeShims(filepath.Join(tempDir, "package"), filepath.Join(installDir, "package"), filepath.Join(tempDir, "bin")); err != nil {
+		return "", err
+	}
+
+	if p.Hash != "" {
+		if err := os.WriteFile(filepath.Join(tempDir, integrityFileName), []byte(p.integrity()+"\n"), 0644); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	// Another run may have installed it in the meantime
+	if p.isInstalled(installDir) {
+		return binDir, nil
+	}
+
+	if err := os.RemoveAll(installDir); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := os.Rename(tempDir, installDir); err != nil {
+		if p.isInstalled(installDir) {
+			return binDir, nil
+		}
+
+		return "", errors.WithStack(err)
+	}
+
+	return binDir, nil
+}
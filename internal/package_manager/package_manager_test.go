@@ -0,0 +1,181 @@
+package package_manager
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		field       string
+		expected    *PackageManager
+		expectError bool
+	}{
+		{field: "pnpm@9.1.0", expected: &PackageManager{Name: "pnpm", Version: "9.1.0"}},
+		{field: "yarn@4.1.0+sha224.ABCDEF", expected: &PackageManager{Name: "yarn", Version: "4.1.0", HashAlgorithm: "sha224", Hash: "abcdef"}},
+		{field: "npm@10.2.4+sha512.123", expected: &PackageManager{Name: "npm", Version: "10.2.4", HashAlgorithm: "sha512", Hash: "123"}},
+		{field: "pnpm@9.1.0-rc.1", expected: &PackageManager{Name: "pnpm", Version: "9.1.0-rc.1"}},
+		{field: "bun@1.0.0", expectError: true},
+		{field: "pnpm", expectError: true},
+		{field: "pnpm@^9", expectError: true},
+		{field: "pnpm@9.1.0+md5.123", expectError: true},
+		{field: "pnpm@9.1.0+sha512", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			pm, err := Parse(tt.field)
+
+			if tt.expectError {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, pm)
+		})
+	}
+}
+
+func TestTarballURL(t *testing.T) {
+	registry := "https://registry.npmjs.org/"
+
+	assert.Equal(t, "https://registry.npmjs.org/pnpm/-/pnpm-9.1.0.tgz", PackageManager{Name: "pnpm", Version: "9.1.0"}.TarballURL(registry))
+	assert.Equal(t, "https://registry.npmjs.org/yarn/-/yarn-1.22.19.tgz", PackageManager{Name: "yarn", Version: "1.22.19"}.TarballURL(registry))
+	assert.Equal(t, "https://registry.npmjs.org/@yarnpkg/cli-dist/-/cli-dist-4.1.0.tgz", PackageManager{Name: "yarn", Version: "4.1.0"}.TarballURL(registry))
+}
+
+func TestWriteShims(t *testing.T) {
+	dir := t.TempDir()
+	packageDir := filepath.Join(dir, "package")
+	binDir := filepath.Join(dir, "bin")
+
+	assert.Nil(t, os.MkdirAll(packageDir, 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(packageDir, "package.json"), []byte(`{"name": "pnpm", "bin": "bin/pnpm.cjs"}`), 0644))
+	installedPackageDir := filepath.Join(dir, "installed", "package")
+
+	assert.Nil(t, writeShims(packageDir, installedPackageDir, binDir))
+
+	script := filepath.Join(installedPackageDir, "bin", "pnpm.cjs")
+
+	if runtime.GOOS == "windows" {
+		content, err := os.ReadFile(filepath.Join(binDir, "pnpm.cmd"))
+		assert.Nil(t, err)
+		assert.Equal(t, "@node \""+script+"\" %*\r\n", string(content))
+	} else {
+		content, err := os.ReadFile(filepath.Join(binDir, "pnpm"))
+		assert.Nil(t, err)
+		assert.Equal(t, "#!/bin/sh\nexec node \""+script+"\" \"$@\"\n", string(content))
+	}
+
+	assert.Nil(t, os.WriteFile(filepath.Join(packageDir, "package.json"), []byte(`{"name": "yarn"}`), 0644))
+	assert.NotNil(t, writeShims(packageDir, installedPackageDir, binDir), "a package without bin")
+}
+
+// newTarball returns a tarball of a package with a pnpm bin, as published in the registry.
+func newTarball(t *testing.T, version string) []byte {
+	var buf bytes.Buffer
+
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for name, content := range map[string]string{
+		"package/package.json": `{"name": "pnpm", "version": "` + version + `", "bin": "bin/pnpm.cjs"}`,
+		"package/bin/pnpm.cjs": "console.log('" + version + "')",
+	} {
+		assert.Nil(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gw.Close())
+
+	return buf.Bytes()
+}
+
+func TestInstallVerifiesCachedHash(t *testing.T) {
+	tarball := newTarball(t, "9.1.0")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(tarball)
+	}))
+	defer server.Close()
+
+	origRegistry := NPM_REGISTRY
+	defer func() { NPM_REGISTRY = origRegistry }()
+	NPM_REGISTRY = server.URL + "/"
+
+	dir := t.TempDir()
+
+	sum := sha512.Sum512(tarball)
+	pm := &PackageManager{Name: "pnpm", Version: "9.1.0", HashAlgorithm: "sha512", Hash: hex.EncodeToString(sum[:])}
+
+	binDir, err := Install(pm, dir)
+
+	assert.Nil(t, err)
+	assert.FileExists(t, filepath.Join(filepath.Dir(binDir), integrityFileName))
+
+	// The install is reused for the same hash
+	_, err = Install(pm, dir)
+	assert.Nil(t, err)
+
+	// A different hash is never satisfied by the cached install, the tarball is downloaded and verified again
+	other := *pm
+	other.Hash = strings.Repeat("0", len(pm.Hash))
+
+	_, err = Install(&other, dir)
+	assert.NotNil(t, err)
+}
+
+func TestInstallConcurrently(t *testing.T) {
+	tarball := newTarball(t, "9.1.0")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(tarball)
+	}))
+	defer server.Close()
+
+	origRegistry := NPM_REGISTRY
+	defer func() { NPM_REGISTRY = origRegistry }()
+	NPM_REGISTRY = server.URL + "/"
+
+	dir := t.TempDir()
+	pm := &PackageManager{Name: "pnpm", Version: "9.1.0"}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			binDir, err := Install(pm, dir)
+
+			assert.Nil(t, err)
+			assert.FileExists(t, filepath.Join(filepath.Dir(binDir), "package", "bin", "pnpm.cjs"))
+		}()
+	}
+
+	wg.Wait()
+
+	// No temporary directory is left behind
+	entries, err := os.ReadDir(filepath.Join(dir, "package-managers"))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, pm.String(), entries[0].Name())
+}
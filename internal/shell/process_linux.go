@@ -0,0 +1,80 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+)
+
+// parseProcStat parses the content of /proc/<pid>/stat and returns the command name and the parent process id.
+// The command name is wrapped in parentheses and may itself contain spaces or parentheses,
+// so the fields after it are located from the last closing parenthesis.
+func parseProcStat(content string) (string, int, error) {
+	start := strings.Index(content, "(")
+	end := strings.LastIndex(content, ")")
+
+	if start < 0 || end < start {
+		return "", -1, errors.New("unexpected format of stat file")
+	}
+
+	name := content[start+1 : end]
+
+	// Fields after the command name: state ppid pgrp ...
+	fields := strings.Fields(content[end+1:])
+
+	if len(fields) < 2 {
+		return "", -1, errors.New("unexpected format of stat file")
+	}
+
+	ppid, err := strconv.Atoi(fields[1])
+
+	if err != nil {
+		return "", -1, errors.WithStack(err)
+	}
+
+	return name, ppid, nil
+}
+
+func getProcessInfoViaProcfs(pid int) (*processInfo, error) {
+	content, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	name, ppid, err := parseProcStat(string(content))
+
+	if err != nil {
+		return nil, err
+	}
+
+	info := &processInfo{
+		PPid:   ppid,
+		Name:   name,
+		Source: "procfs",
+	}
+
+	// The exe link is not readable for processes of other users, the name is enough in that case
+	if exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid)); err == nil {
+		info.Exe = exe
+	}
+
+	return info, nil
+}
+
+func getProcessInfo(pid int) (*processInfo, error) {
+	info, err := getProcessInfoViaProcfs(pid)
+
+	if err == nil {
+		return info, nil
+	}
+
+	// procfs may not be mounted, e.g. in some sandboxes
+	util.Debug("Shell detection: procfs is not available: %v, fallback to ps\n", err)
+
+	return getProcessInfoViaPs(pid)
+}
@@ -0,0 +1,73 @@
+package shell
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_parseProcStat(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		expectedName string
+		expectedPPid int
+		expectError  bool
+	}{
+		{
+			name:         "simple command",
+			content:      "1234 (bash) S 1000 1234 1234 34816 1234 4194304",
+			expectedName: "bash",
+			expectedPPid: 1000,
+		},
+		{
+			name:         "command with spaces and parentheses",
+			content:      "42 (tmux: server (1)) S 1 42 42 0 -1 4194624",
+			expectedName: "tmux: server (1)",
+			expectedPPid: 1,
+		},
+		{
+			name:        "missing parentheses",
+			content:     "42 bash S 1",
+			expectError: true,
+		},
+		{
+			name:        "truncated content",
+			content:     "42 (bash) S",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, ppid, err := parseProcStat(tt.content)
+
+			if (err != nil) != tt.expectError {
+				t.Fatalf("expected error: %v, got: %v", tt.expectError, err)
+			}
+
+			if tt.expectError {
+				return
+			}
+
+			if name != tt.expectedName || ppid != tt.expectedPPid {
+				t.Errorf("expected (%s, %d), got (%s, %d)", tt.expectedName, tt.expectedPPid, name, ppid)
+			}
+		})
+	}
+}
+
+func Test_getProcessInfoViaProcfs(t *testing.T) {
+	info, err := getProcessInfoViaProcfs(os.Getpid())
+
+	if err != nil {
+		t.Skipf("procfs is not available: %v", err)
+	}
+
+	if info.PPid != os.Getppid() {
+		t.Errorf("expected ppid %d, got %d", os.Getppid(), info.PPid)
+	}
+
+	if info.Exe == "" {
+		t.Errorf("expected the executable path of the current process")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !windows && !linux
+
+package shell
+
+func getProcessInfo(pid int) (*processInfo, error) {
+	return getProcessInfoViaPs(pid)
+}
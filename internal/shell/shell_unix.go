@@ -12,9 +12,18 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/axetroy/nodapt/internal/util"
 	"github.com/pkg/errors"
 )
 
+// processInfo describes a process in the ancestor chain of nodapt.
+type processInfo struct {
+	PPid   int    // The parent process id
+	Name   string // The command name of the process, e.g. "bash"
+	Exe    string // The absolute path of the executable if known
+	Source string // Where the information comes from, "procfs" or "ps"
+}
+
 func getShellFromEnv() *string {
 	shell := os.Getenv("SHELL")
 
@@ -25,68 +34,91 @@ func getShellFromEnv() *string {
 	return &shell
 }
 
-var knownShells = []string{"sh", "bash", "zsh", "fish", "dash", "ash", "ksh"}
+var knownShells = []string{"sh", "bash", "zsh", "fish", "dash", "ash", "ksh", "mksh", "tcsh", "csh", "nu", "elvish", "xonsh", "pwsh"}
 
 func isKnownShell(shellPath string) bool {
 	parts := strings.Split(shellPath, "/")
 
-	executableFileName := parts[len(parts)-1]
+	// Login shells are reported with a leading dash, e.g. "-bash"
+	executableFileName := strings.TrimPrefix(parts[len(parts)-1], "-")
 
 	return slices.Contains(knownShells, executableFileName)
 }
 
-func getParentProcessInfo(pid int) (int, string, error) {
+func getProcessInfoViaPs(pid int) (*processInfo, error) {
 	cmd := exec.Command("ps", "-o", "ppid,comm", "-p", fmt.Sprintf("%d", pid))
 	var out bytes.Buffer
 	cmd.Stdout = &out
 
 	err := cmd.Run()
 	if err != nil {
-		return -1, "", err
+		return nil, err
 	}
 
 	lines := strings.Split(out.String(), "\n")
 	if len(lines) < 2 {
-		return -1, "", errors.New("no output from ps")
+		return nil, errors.New("no output from ps")
 	}
 
 	fields := strings.Fields(lines[1])
 	if len(fields) < 2 {
-		return -1, "", errors.New("unexpected output format")
+		return nil, errors.New("unexpected output format")
 	}
 
-	ppid := fields[0]
-	command := fields[1]
-
-	ppidInt, err := strconv.Atoi(ppid)
+	ppid, err := strconv.Atoi(fields[0])
 
 	if err != nil {
-		return -1, "", err
+		return nil, err
 	}
 
-	return ppidInt, command, nil
+	// The command may contain spaces on some platforms
+	command := strings.Join(fields[1:], " ")
+
+	info := &processInfo{
+		PPid:   ppid,
+		Name:   command,
+		Source: "ps",
+	}
+
+	if path.IsAbs(command) {
+		info.Exe = command
+	}
+
+	return info, nil
 }
 
 func getShellFromProcess(pid int) (string, error) {
 	const maxDepth = 100 // Prevent infinite loop by limiting parent process traversal
-	
+
 	for i := 0; i < maxDepth; i++ {
-		ppid, command, err := getParentProcessInfo(pid)
+		info, err := getProcessInfo(pid)
 
 		if err != nil {
+			util.Debug("Shell detection: failed to inspect process %d: %v\n", pid, err)
 			return "", err
 		}
 
-		// Stop if we've reached init process (pid 1) or invalid pid
-		if ppid <= 1 {
-			return "", errors.New("reached root process without finding known shell")
+		util.Debug("Shell detection: pid=%d ppid=%d name=%q exe=%q source=%s\n", pid, info.PPid, info.Name, info.Exe, info.Source)
+
+		// Prefer the executable path since it is absolute and never truncated
+		if info.Exe != "" && isKnownShell(info.Exe) {
+			util.Debug("Shell detection: picked %s from process %d\n", info.Exe, pid)
+			return info.Exe, nil
 		}
 
-		if isKnownShell(command) {
-			return command, nil
+		// Scripted shells (e.g. xonsh) run under an interpreter, so fall back to the command name
+		if isKnownShell(info.Name) {
+			shell := strings.TrimPrefix(info.Name, "-")
+			util.Debug("Shell detection: picked %s from process %d\n", shell, pid)
+			return shell, nil
 		}
 
-		pid = ppid
+		// Stop if we've reached init process (pid 1) or invalid pid
+		if pid <= 1 || info.PPid <= 0 {
+			return "", errors.New("reached root process without finding known shell")
+		}
+
+		pid = info.PPid
 	}
 
 	return "", errors.New("exceeded maximum depth searching for parent shell")
@@ -100,10 +132,15 @@ func GetPath() (string, error) {
 			if fullShellPath, err := exec.LookPath(shell); err == nil {
 				return fullShellPath, nil
 			}
+
+			util.Debug("Shell detection: %s is not found in PATH\n", shell)
 		}
+	} else {
+		util.Debug("Shell detection: no known shell in the process tree: %v\n", err)
 	}
 
 	if shell := getShellFromEnv(); shell != nil {
+		util.Debug("Shell detection: fallback to $SHELL %s\n", *shell)
 		return *shell, nil
 	}
 
@@ -22,3 +22,30 @@ func Test_getShell(t *testing.T) {
 	}
 
 }
+
+func Test_isKnownShell(t *testing.T) {
+	tests := []struct {
+		shellPath string
+		expected  bool
+	}{
+		{"bash", true},
+		{"/bin/zsh", true},
+		{"-bash", true},
+		{"/usr/bin/nu", true},
+		{"elvish", true},
+		{"xonsh", true},
+		{"/bin/tcsh", true},
+		{"csh", true},
+		{"/usr/bin/pwsh", true},
+		{"node", false},
+		{"sshd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shellPath, func(t *testing.T) {
+			if result := isKnownShell(tt.shellPath); result != tt.expected {
+				t.Errorf("isKnownShell(%s) = %v, expected %v", tt.shellPath, result, tt.expected)
+			}
+		})
+	}
+}
@@ -7,55 +7,52 @@ import (
 	"strings"
 )
 
-func removeFromSlice(s []string, r string) []string {
-	result := s[:0] // Use the same slice to avoid extra allocations
-	for _, v := range s {
-		if v != r {
-			result = append(result, v)
-		}
+// containsNodeBinary reports whether the directory contains the node binary.
+func containsNodeBinary(dir string) bool {
+	stat, err := os.Stat(dir)
+
+	if err != nil || !stat.IsDir() {
+		return false
 	}
-	return result
-}
 
-func removeNodePath(paths string) string {
-	// Split the PATH into directories
-	dirs := strings.Split(paths, string(os.PathListSeparator))
+	files, err := os.ReadDir(dir)
 
-	// Remove the node binary directory from the PATH
-	for _, dir := range dirs {
-		stat, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
 
-		if err != nil {
-			continue
+	for _, file := range files {
+		if (runtime.GOOS == "windows" && strings.EqualFold(file.Name(), "node.exe")) || (runtime.GOOS != "windows" && file.Name() == "node") {
+			return true
 		}
+	}
 
-		if !stat.IsDir() {
-			continue
-		}
+	return false
+}
 
-		// Check if the directory contains the node binary
-		files, err := os.ReadDir(dir)
-		if err != nil {
-			continue
-		}
+func removeNodePath(paths string) string {
+	// Split the PATH into directories
+	dirs := strings.Split(paths, string(os.PathListSeparator))
 
-	fileLoop:
-		for _, file := range files {
-			isContainsNodeBinary := (runtime.GOOS == "windows" && file.Name() == "node.exe") || (runtime.GOOS != "windows" && file.Name() == "node")
+	result := make([]string, 0, len(dirs))
 
-			if isContainsNodeBinary {
-				// Remove the directory from the PATH
-				dirs = removeFromSlice(dirs, dir)
-				break fileLoop
-			}
+	// Remove the directories containing the node binary from the PATH
+	for _, dir := range dirs {
+		if !containsNodeBinary(dir) {
+			result = append(result, dir)
 		}
 	}
 
-	return strings.Join(dirs, string(os.PathListSeparator))
+	return strings.Join(result, string(os.PathListSeparator))
 }
 
-func AppendEnvPath(pathDir string) string {
-	oldPath := removeNodePath(os.Getenv("PATH"))
+// AppendEnvPath returns paths with pathDir prepended and the directories containing other node binaries removed.
+//
+// Parameters:
+//   - paths: The value of a PATH environment variable.
+//   - pathDir: The directory to prepend.
+func AppendEnvPath(paths string, pathDir string) string {
+	oldPath := removeNodePath(paths)
 
 	// Prepend pathDir to the PATH
 	newPath := fmt.Sprintf("%s%c%s", pathDir, os.PathListSeparator, oldPath)
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRemoveNodePath(t *testing.T) {
+	root := t.TempDir()
+
+	nodeFileName := "node"
+
+	if runtime.GOOS == "windows" {
+		nodeFileName = "node.exe"
+	}
+
+	dirs := map[string]bool{"a": true, "b": true, "c": false, "d": true}
+
+	for name, hasNode := range dirs {
+		dir := filepath.Join(root, name)
+		_ = os.MkdirAll(dir, 0755)
+
+		if hasNode {
+			_ = os.WriteFile(filepath.Join(dir, nodeFileName), []byte(""), 0755)
+		}
+	}
+
+	sep := string(os.PathListSeparator)
+	missing := filepath.Join(root, "missing")
+
+	paths := strings.Join([]string{
+		filepath.Join(root, "a"),
+		filepath.Join(root, "b"),
+		filepath.Join(root, "c"),
+		missing,
+		filepath.Join(root, "d"),
+	}, sep)
+
+	expected := strings.Join([]string{filepath.Join(root, "c"), missing}, sep)
+
+	if result := removeNodePath(paths); result != expected {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+
+	if result := AppendEnvPath(paths, "/opt/node/bin"); result != "/opt/node/bin"+sep+expected {
+		t.Errorf("expected the directory to be prepended, got %s", result)
+	}
+}
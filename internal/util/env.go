@@ -0,0 +1,132 @@
+package util
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Env is the environment of a child process in "KEY=VALUE" form.
+// It is built from a copy of a base environment, so changing it never affects the environment of nodapt itself.
+// Keys are case-insensitive on Windows.
+type Env struct {
+	vars []string
+}
+
+// NewEnv creates an environment from a copy of base, which is usually os.Environ().
+func NewEnv(base []string) *Env {
+	vars := make([]string, len(base))
+	copy(vars, base)
+
+	return &Env{vars: vars}
+}
+
+func isSameEnvKey(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+
+	return a == b
+}
+
+// Get returns the value of the environment variable key, or an empty string if it is not set.
+func (e *Env) Get(key string) string {
+	for _, kv := range e.vars {
+		if k, v, ok := strings.Cut(kv, "="); ok && isSameEnvKey(k, key) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// Set sets the environment variable key to value, replacing any existing value.
+func (e *Env) Set(key string, value string) {
+	vars := e.vars[:0]
+
+	for _, kv := range e.vars {
+		if k, _, _ := strings.Cut(kv, "="); !isSameEnvKey(k, key) {
+			vars = append(vars, kv)
+		}
+	}
+
+	e.vars = append(vars, key+"="+value)
+}
+
+// Environ returns a copy of the environment, suitable for exec.Cmd.Env.
+func (e *Env) Environ() []string {
+	vars := make([]string, len(e.vars))
+	copy(vars, e.vars)
+
+	return vars
+}
+
+// defaultPathExtensions are the extensions of executables on Windows when PATHEXT is not set.
+var defaultPathExtensions = []string{".com", ".exe", ".bat", ".cmd"}
+
+// pathExtensions returns the lowercase extensions of executables on Windows from the PATHEXT of this environment,
+// falling back to defaultPathExtensions when it is empty.
+func (e *Env) pathExtensions() []string {
+	var extensions []string
+
+	for _, ext := range strings.Split(strings.ToLower(e.Get("PATHEXT")), ";") {
+		if ext == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		extensions = append(extensions, ext)
+	}
+
+	if len(extensions) == 0 {
+		return defaultPathExtensions
+	}
+
+	return extensions
+}
+
+// LookPath searches for an executable named file in the directories of the PATH of this environment.
+// It behaves like exec.LookPath, which always uses the PATH and PATHEXT of nodapt itself.
+func (e *Env) LookPath(file string) (string, error) {
+	if strings.ContainsAny(file, `/\`) {
+		return exec.LookPath(file)
+	}
+
+	suffixes := []string{""}
+	executable := isExecutable
+
+	if runtime.GOOS == "windows" {
+		pathExtensions := e.pathExtensions()
+
+		if !slices.Contains(pathExtensions, strings.ToLower(filepath.Ext(file))) {
+			suffixes = pathExtensions
+		}
+
+		executable = func(info os.FileInfo, filePath string) bool {
+			return !info.IsDir() && slices.Contains(pathExtensions, strings.ToLower(filepath.Ext(filePath)))
+		}
+	}
+
+	for _, dir := range filepath.SplitList(e.Get("PATH")) {
+		// Skip empty entries rather than resolving them to the current directory
+		if dir == "" {
+			continue
+		}
+
+		for _, suffix := range suffixes {
+			filePath := filepath.Join(dir, file+suffix)
+
+			if info, err := os.Stat(filePath); err == nil && executable(info, filePath) {
+				return filePath, nil
+			}
+		}
+	}
+
+	return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+}
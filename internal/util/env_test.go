@@ -0,0 +1,92 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "HOME=/home/user", "EMPTY="}
+
+	env := NewEnv(base)
+
+	env.Set("PATH", "/opt/node/bin:/usr/bin")
+	env.Set("NPM_CONFIG_PREFIX", "/opt/node")
+
+	if env.Get("PATH") != "/opt/node/bin:/usr/bin" {
+		t.Errorf("expected PATH to be overridden, got %s", env.Get("PATH"))
+	}
+
+	if env.Get("NOT_EXIST") != "" {
+		t.Errorf("expected empty value for a missing key, got %s", env.Get("NOT_EXIST"))
+	}
+
+	result := env.Environ()
+	slices.Sort(result)
+
+	expected := []string{"EMPTY=", "HOME=/home/user", "NPM_CONFIG_PREFIX=/opt/node", "PATH=/opt/node/bin:/usr/bin"}
+
+	if !slices.Equal(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+
+	if base[0] != "PATH=/usr/bin" {
+		t.Errorf("base environment should not be modified, got %v", base)
+	}
+}
+
+func TestEnvLookPath(t *testing.T) {
+	dir := t.TempDir()
+
+	name := "nodapt-test-bin"
+	fileName := name
+
+	if runtime.GOOS == "windows" {
+		fileName += ".cmd"
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, fileName), []byte(""), 0755); err != nil {
+		t.Fatalf("Failed to create executable: %v", err)
+	}
+
+	env := NewEnv([]string{"PATH=" + filepath.Join(dir, "missing") + string(os.PathListSeparator) + dir})
+
+	result, err := env.LookPath(name)
+
+	if err != nil {
+		t.Fatalf("LookPath() error = %v", err)
+	}
+
+	if result != filepath.Join(dir, fileName) {
+		t.Errorf("expected %s, got %s", filepath.Join(dir, fileName), result)
+	}
+
+	if _, err := NewEnv([]string{"PATH="}).LookPath(name); err == nil {
+		t.Errorf("expected an error when the executable is not in PATH")
+	}
+}
+
+func TestEnvPathExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		pathExt  string
+		expected []string
+	}{
+		{name: "not set", pathExt: "", expected: defaultPathExtensions},
+		{name: "only separators", pathExt: ";;", expected: defaultPathExtensions},
+		{name: "custom", pathExt: ".COM;.EXE;.PS1;;js", expected: []string{".com", ".exe", ".ps1", ".js"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := NewEnv([]string{"PATHEXT=" + tt.pathExt})
+
+			if result := env.pathExtensions(); !slices.Equal(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// PrefixWriter writes every line to the underlying writer with a prefix. Complete lines are written at once
+// while holding the lock, so the lines of several writers sharing the lock don't interleave.
+type PrefixWriter struct {
+	w      io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+// NewPrefixWriter returns a PrefixWriter, the lock may be shared by the writers of the same underlying writer.
+func NewPrefixWriter(w io.Writer, prefix string, mu *sync.Mutex) *PrefixWriter {
+	return &PrefixWriter{w: w, prefix: prefix, mu: mu}
+}
+
+func (p *PrefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+
+	end := bytes.LastIndexByte(p.buf, '\n')
+
+	if end < 0 {
+		return len(b), nil
+	}
+
+	if err := p.writeLines(p.buf[:end+1]); err != nil {
+		return 0, err
+	}
+
+	p.buf = append(p.buf[:0], p.buf[end+1:]...)
+
+	return len(b), nil
+}
+
+// Flush writes the last line even if it doesn't end with a newline.
+func (p *PrefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+
+	err := p.writeLines(append(p.buf, '\n'))
+
+	p.buf = p.buf[:0]
+
+	return err
+}
+
+func (p *PrefixWriter) writeLines(lines []byte) error {
+	var out bytes.Buffer
+
+	for _, line := range bytes.SplitAfter(lines, []byte{'\n'}) {
+		if len(line) > 0 {
+			out.WriteString(p.prefix)
+			out.Write(line)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err := p.w.Write(out.Bytes())
+
+	return err
+}
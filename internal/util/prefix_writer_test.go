@@ -0,0 +1,40 @@
+package util
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		writes   []string
+		expected string
+	}{
+		{name: "Single line", writes: []string{"hello\n"}, expected: "a | hello\n"},
+		{name: "Several lines", writes: []string{"one\ntwo\n"}, expected: "a | one\na | two\n"},
+		{name: "Line split across writes", writes: []string{"hel", "lo\nwor", "ld\n"}, expected: "a | hello\na | world\n"},
+		{name: "Unterminated last line", writes: []string{"one\ntwo"}, expected: "a | one\na | two\n"},
+		{name: "Empty lines", writes: []string{"\n\n"}, expected: "a | \na | \n"},
+		{name: "Nothing", writes: nil, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := NewPrefixWriter(&out, "a | ", &sync.Mutex{})
+
+			for _, s := range tt.writes {
+				n, err := w.Write([]byte(s))
+				assert.NoError(t, err)
+				assert.Equal(t, len(s), n)
+			}
+
+			assert.NoError(t, w.Flush())
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package util
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a human readable size such as "5GB", "512M" or "1.5g" into bytes.
+// Units are powers of 1024, a number without unit is in bytes.
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid size %q", s)
+	}
+
+	return int64(n * float64(multiplier)), nil
+}
+
+// FormatSize formats bytes into a human readable size such as "1.5 GB".
+func FormatSize(bytes int64) string {
+	for _, unit := range sizeUnits[:4] {
+		if bytes >= unit.size {
+			return fmt.Sprintf("%.1f %s", float64(bytes)/float64(unit.size), unit.suffix)
+		}
+	}
+
+	return fmt.Sprintf("%d B", bytes)
+}
+
+// ParseDuration parses a duration such as "30d", "2w" or any value accepted by time.ParseDuration.
+func ParseDuration(s string) (time.Duration, error) {
+	value := strings.TrimSpace(s)
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(value, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(value, suffix), 64)
+
+			if err != nil || n < 0 {
+				return 0, errors.Errorf("invalid duration %q", s)
+			}
+
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+
+	if err != nil {
+		return 0, errors.Errorf("invalid duration %q", s)
+	}
+
+	return d, nil
+}
+
+// DirSize returns the total size in bytes of the regular files in the directory.
+func DirSize(dir string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+
+			if err != nil {
+				return err
+			}
+
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return size, nil
+}
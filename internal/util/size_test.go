@@ -0,0 +1,82 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    int64
+		expectError bool
+	}{
+		{"1024", 1024, false},
+		{"5GB", 5 << 30, false},
+		{"5gb", 5 << 30, false},
+		{"512M", 512 << 20, false},
+		{"1.5 KB", 1536, false},
+		{"10B", 10, false},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"-1GB", 0, true},
+	}
+
+	for _, test := range tests {
+		result, err := ParseSize(test.input)
+		if test.expectError {
+			assert.Error(t, err, test.input)
+		} else {
+			assert.NoError(t, err, test.input)
+			assert.Equal(t, test.expected, result, test.input)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", FormatSize(512))
+	assert.Equal(t, "1.5 KB", FormatSize(1536))
+	assert.Equal(t, "5.0 GB", FormatSize(5<<30))
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"1.5d", 36 * time.Hour, false},
+		{"d", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, test := range tests {
+		result, err := ParseDuration(test.input)
+		if test.expectError {
+			assert.Error(t, err, test.input)
+		} else {
+			assert.NoError(t, err, test.input)
+			assert.Equal(t, test.expected, result, test.input)
+		}
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+
+	_ = os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "a.txt"), make([]byte, 100), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "sub", "b.txt"), make([]byte, 50), 0644)
+
+	size, err := DirSize(dir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), size)
+}
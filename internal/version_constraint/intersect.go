@@ -0,0 +1,30 @@
+package version_constraint
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Intersect returns a constraint which is satisfied by the versions satisfying both constraints.
+// Every alternative of a is combined with every alternative of b, e.g. "^18 || ^20" and ">=18.5"
+// gives "^18, >=18.5 || ^20, >=18.5". It doesn't tell whether any version satisfies the result.
+func Intersect(a string, b string) (string, error) {
+	for _, c := range []string{a, b} {
+		if _, err := semver.NewConstraint(c); err != nil {
+			return "", errors.WithMessagef(err, "failed to parse version range %s", c)
+		}
+	}
+
+	var alternatives []string
+
+	for _, x := range strings.Split(a, "||") {
+		for _, y := range strings.Split(b, "||") {
+			alternatives = append(alternatives, strings.TrimSpace(x)+", "+strings.TrimSpace(y))
+		}
+	}
+
+	return strings.Join(alternatives, " || "), nil
+}
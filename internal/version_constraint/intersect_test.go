@@ -0,0 +1,48 @@
+package version_constraint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntersect(t *testing.T) {
+	tests := []struct {
+		a           string
+		b           string
+		matches     []string
+		mismatches  []string
+		expectError bool
+	}{
+		{a: "^18 || ^20", b: ">=18.5", matches: []string{"18.6.0", "20.1.0"}, mismatches: []string{"18.4.0", "21.0.0"}},
+		{a: ">=18 <21", b: "20.x", matches: []string{"20.1.0"}, mismatches: []string{"18.6.0", "21.0.0"}},
+		{a: "1.2.3 - 1.2.5", b: "^1", matches: []string{"1.2.4"}, mismatches: []string{"1.3.0"}},
+		{a: "^18", b: "^20", mismatches: []string{"18.0.0", "20.0.0"}},
+		{a: "invalid", b: "^20", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" and "+tt.b, func(t *testing.T) {
+			constraint, err := Intersect(tt.a, tt.b)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			for _, v := range tt.matches {
+				ok, err := Match(constraint, v)
+				assert.NoError(t, err)
+				assert.True(t, ok, "%s should satisfy %s", v, constraint)
+			}
+
+			for _, v := range tt.mismatches {
+				ok, err := Match(constraint, v)
+				assert.NoError(t, err)
+				assert.False(t, ok, "%s should not satisfy %s", v, constraint)
+			}
+		})
+	}
+}
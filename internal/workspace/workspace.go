@@ -0,0 +1,224 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/axetroy/nodapt/internal/util"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const pnpmWorkspaceFileName = "pnpm-workspace.yaml"
+
+// Workspace is a npm, yarn or pnpm workspace.
+type Workspace struct {
+	Root     string   // The root directory of the workspace
+	File     string   // The file which declares the workspace, package.json or pnpm-workspace.yaml
+	Patterns []string // The globs of the package directories relative to Root, a leading ! excludes them
+}
+
+// Find returns the workspace which contains the package in dir, looking up from dir.
+// A workspace whose patterns don't match the package is skipped. It returns nil if there is none.
+func Find(dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	root := dir
+
+	for {
+		ws, err := read(root)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ws != nil && ws.Contains(dir) {
+			return ws, nil
+		}
+
+		parentDir := filepath.Dir(root)
+
+		if parentDir == root {
+			return nil, nil
+		}
+
+		root = parentDir
+	}
+}
+
+// read returns the workspace declared in dir, or nil if there is none.
+// pnpm-workspace.yaml takes precedence over the workspaces field of package.json, as pnpm ignores the latter.
+func read(dir string) (*Workspace, error) {
+	pnpmWorkspaceFile := filepath.Join(dir, pnpmWorkspaceFileName)
+
+	if util.IsFileExist(pnpmWorkspaceFile) {
+		content, err := os.ReadFile(pnpmWorkspaceFile)
+
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var config struct {
+			Packages []string `yaml:"packages"`
+		}
+
+		if err := yaml.Unmarshal(content, &config); err != nil {
+			return nil, errors.WithMessagef(err, "failed to parse %s", pnpmWorkspaceFile)
+		}
+
+		return &Workspace{Root: dir, File: pnpmWorkspaceFile, Patterns: config.Packages}, nil
+	}
+
+	packageJSONFile := filepath.Join(dir, "package.json")
+
+	if !util.IsFileExist(packageJSONFile) {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(packageJSONFile)
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var packageJSON struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+
+	if err := json.Unmarshal(content, &packageJSON); err != nil {
+		return nil, errors.WithMessagef(err, "failed to parse %s", packageJSONFile)
+	}
+
+	if len(packageJSON.Workspaces) == 0 || string(packageJSON.Workspaces) == "null" {
+		return nil, nil
+	}
+
+	var patterns []string
+
+	// The workspaces field is an array of globs, or an object with the globs in packages as yarn v1 supports
+	if err := json.Unmarshal(packageJSON.Workspaces, &patterns); err != nil {
+		var workspaces struct {
+			Packages []string `json:"packages"`
+		}
+
+		if err := json.Unmarshal(packageJSON.Workspaces, &workspaces); err != nil {
+			return nil, errors.Errorf("invalid workspaces of %s, expected an array of globs", packageJSONFile)
+		}
+
+		patterns = workspaces.Packages
+	}
+
+	return &Workspace{Root: dir, File: packageJSONFile, Patterns: patterns}, nil
+}
+
+// Contains reports whether dir is the root or a package of the workspace.
+func (w *Workspace) Contains(dir string) bool {
+	rel, err := filepath.Rel(w.Root, dir)
+
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	if rel == "." {
+		return true
+	}
+
+	return w.match(filepath.ToSlash(rel))
+}
+
+// match reports whether the relative path of a directory matches the patterns, the last matching pattern wins.
+func (w *Workspace) match(rel string) bool {
+	matched := false
+
+	for _, pattern := range w.Patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+
+		if MatchGlob(strings.TrimPrefix(pattern, "!"), rel) {
+			matched = !exclude
+		}
+	}
+
+	return matched
+}
+
+// MatchGlob reports whether the slash separated path matches the glob, where ** matches any number of segments.
+func MatchGlob(pattern string, p string) bool {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+
+	return matchGlob(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+// matchGlob matches the segments of a path with the segments of a glob, where ** matches any number of segments.
+func matchGlob(pattern []string, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+		return false
+	}
+
+	return matchGlob(pattern[1:], segments[1:])
+}
+
+// Packages returns the directories of the packages of the workspace, without the root, sorted by path.
+// node_modules and hidden directories are never searched.
+func (w *Workspace) Packages() ([]string, error) {
+	var packages []string
+
+	err := filepath.WalkDir(w.Root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() || p == w.Root {
+			return nil
+		}
+
+		if d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(w.Root, p)
+
+		if err != nil {
+			return err
+		}
+
+		if w.match(filepath.ToSlash(rel)) && util.IsFileExist(filepath.Join(p, "package.json")) {
+			packages = append(packages, p)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sort.Strings(packages)
+
+	return packages, nil
+}
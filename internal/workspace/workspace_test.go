@@ -0,0 +1,146 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		dir      string
+		expected string // The root relative to the temporary directory, empty for no workspace
+	}{
+		{
+			name: "npm workspaces",
+			files: map[string]string{
+				"package.json":            `{"workspaces": ["packages/*"]}`,
+				"packages/a/package.json": `{"name": "a"}`,
+			},
+			dir:      "packages/a",
+			expected: ".",
+		},
+		{
+			name: "yarn workspaces object",
+			files: map[string]string{
+				"package.json":            `{"workspaces": {"packages": ["packages/*"]}}`,
+				"packages/a/package.json": `{"name": "a"}`,
+			},
+			dir:      "packages/a",
+			expected: ".",
+		},
+		{
+			name: "pnpm workspace",
+			files: map[string]string{
+				"pnpm-workspace.yaml":      "packages:\n  - 'apps/**'\n  - '!**/test/**'\n",
+				"apps/web/ui/package.json": `{"name": "ui"}`,
+			},
+			dir:      "apps/web/ui",
+			expected: ".",
+		},
+		{
+			name: "Excluded by pnpm workspace",
+			files: map[string]string{
+				"pnpm-workspace.yaml":        "packages:\n  - 'apps/**'\n  - '!**/test/**'\n",
+				"apps/web/test/package.json": `{"name": "test"}`,
+			},
+			dir:      "apps/web/test",
+			expected: "",
+		},
+		{
+			name: "Not a package of the workspace",
+			files: map[string]string{
+				"package.json":         `{"workspaces": ["packages/*"]}`,
+				"tools/x/package.json": `{"name": "x"}`,
+			},
+			dir:      "tools/x",
+			expected: "",
+		},
+		{
+			name: "The root itself",
+			files: map[string]string{
+				"package.json": `{"workspaces": ["packages/*"]}`,
+			},
+			dir:      ".",
+			expected: ".",
+		},
+		{
+			name: "Nested package is skipped for the workspace containing it",
+			files: map[string]string{
+				"package.json":                `{"workspaces": ["packages/*"]}`,
+				"packages/a/package.json":     `{"workspaces": ["sub/*"]}`,
+				"packages/a/lib/package.json": `{"name": "lib"}`,
+			},
+			dir:      "packages/a/lib",
+			expected: "",
+		},
+		{
+			name:     "No workspace",
+			files:    map[string]string{"package.json": `{"name": "x"}`},
+			dir:      ".",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := filepath.EvalSymlinks(t.TempDir())
+			assert.NoError(t, err)
+
+			writeFiles(t, dir, tt.files)
+
+			ws, err := Find(filepath.Join(dir, tt.dir))
+			assert.NoError(t, err)
+
+			if tt.expected == "" {
+				assert.Nil(t, ws)
+			} else if assert.NotNil(t, ws) {
+				assert.Equal(t, filepath.Join(dir, tt.expected), ws.Root)
+			}
+		})
+	}
+}
+
+func TestPackages(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+
+	writeFiles(t, dir, map[string]string{
+		"package.json":                           `{"workspaces": ["packages/*", "apps/**", "!apps/legacy"]}`,
+		"packages/a/package.json":                `{"name": "a"}`,
+		"packages/b/package.json":                `{"name": "b"}`,
+		"packages/no-package/README.md":          "",
+		"packages/a/node_modules/x/package.json": `{"name": "x"}`,
+		"apps/web/package.json":                  `{"name": "web"}`,
+		"apps/web/admin/package.json":            `{"name": "admin"}`,
+		"apps/legacy/package.json":               `{"name": "legacy"}`,
+		".cache/package.json":                    `{"name": "cache"}`,
+	})
+
+	ws, err := Find(dir)
+	assert.NoError(t, err)
+
+	packages, err := ws.Packages()
+	assert.NoError(t, err)
+
+	expected := []string{
+		filepath.Join(dir, "apps", "web"),
+		filepath.Join(dir, "apps", "web", "admin"),
+		filepath.Join(dir, "packages", "a"),
+		filepath.Join(dir, "packages", "b"),
+	}
+
+	assert.Equal(t, expected, packages)
+}